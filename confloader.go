@@ -3,7 +3,7 @@
 // license that can be found in the LICENSE file.
 
 // Package confloader is a simple configuration file loader that
-// accepts both JSON and YAML file formats.
+// accepts JSON, YAML and HCL file formats.
 //
 // Configuration file (JSON):
 //  {
@@ -28,55 +28,917 @@
 package confloader
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
+	"mime"
+	"net"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/hcl"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	yaml "gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
+// Option alters the way Load reads and interprets a configuration file.
+type Option func(*options)
+
+// options holds the settings set by Option functions.
+type options struct {
+	preserveHash  bool
+	envJSONArrays bool
+	recursiveEnv  bool
+	keyNormalizer func(string) string
+	noFlatten     bool
+	fileValues    bool
+	appendArrays  bool
+	csvArrays     bool
+	allowEmpty    bool
+	maxSize       int64
+	rootKey       string
+	httpClient    *http.Client
+	styleless     bool
+	caseless      bool
+	delimiter     string
+
+	// raw disables the expansion of values, for trees that were already
+	// expanded.
+	raw bool
+
+	// strict makes parse fail on duplicate keys. See LoadStrict.
+	strict bool
+
+	// validate is called with the parsed document before it is flattened.
+	validate func(tree interface{}) error
+
+	// baseDir is the directory of the configuration file, from which
+	// files referenced by values are resolved.
+	baseDir string
+
+	// filename is the name of the configuration file being loaded, if
+	// any, which is reported in parse errors.
+	filename string
+
+	// keyOrder makes parse record the declaration order of the keys in
+	// order, if the file format preserves it. See LoadOrdered.
+	keyOrder bool
+	order    []string
+}
+
+// WithPreserveHashInValues makes Load keep the # character in unquoted YAML
+// values. By default YAML treats " #" as the start of a comment, so a value
+// like "foo # bar" is truncated to "foo". With this option, such values are
+// quoted before parsing. Comments on their own line and the content of
+// block scalars are left untouched. Values in flow collections, like
+// "[foo # bar]", are not quoted.
+func WithPreserveHashInValues() Option {
+	return func(o *options) {
+		o.preserveHash = true
+	}
+}
+
+// WithEnvJSONArrays makes Load parse environment variable values that are
+// JSON arrays or objects. For instance, with LIST='["a","b"]', the parameter
+// "list": "${LIST}" becomes a string array with its index keys "list.0"
+// and "list.1", as if the array had been written in the file. Likewise,
+// with OBJ='{"a":1}', "obj": "${OBJ}" becomes the parameter "obj.a".
+func WithEnvJSONArrays() Option {
+	return func(o *options) {
+		o.envJSONArrays = true
+	}
+}
+
+// WithRecursiveEnv makes Load expand environment variables whose value
+// references another environment variable. For instance, with A='${B}'
+// and B=foo, "${A}" becomes "foo". Expansion stops after a bounded number
+// of passes and Load returns an error, which protects against cycles.
+func WithRecursiveEnv() Option {
+	return func(o *options) {
+		o.recursiveEnv = true
+	}
+}
+
+// WithKeyNormalizer makes Load apply fn to each key segment of the
+// configuration file, so that parameters can be accessed with canonical
+// keys. For instance, fn can convert camelCase keys to snake_case. Load
+// returns an error if two keys of the same object are normalized to the
+// same key.
+func WithKeyNormalizer(fn func(string) string) Option {
+	return func(o *options) {
+		o.keyNormalizer = fn
+	}
+}
+
+// WithoutFlatten makes Load skip flattening: the Config holds the top-level
+// parameters of the file and objects and arrays are kept as nested
+// map[string]interface{} and []interface{} values. This saves work when
+// the Config is only decoded with Unmarshal, but the Get methods cannot
+// access nested parameters with dotted keys anymore.
+func WithoutFlatten() Option {
+	return func(o *options) {
+		o.noFlatten = true
+	}
+}
+
+// WithFileValueExpansion makes Load replace string values starting with @
+// by the content of the file they reference. For instance, "@certs/ca.pem"
+// is replaced by the content of the file certs/ca.pem, relative to the
+// directory of the configuration file. A leading @ is escaped with @@, so
+// "@@foo" becomes "@foo". Load returns an error if a file cannot be read.
+func WithFileValueExpansion() Option {
+	return func(o *options) {
+		o.fileValues = true
+	}
+}
+
+// WithCSVArrays makes Load split the values of environment variables
+// containing commas into arrays, which can then be read with the array
+// getters. For instance, with FLAGS="true,false", "${FLAGS}" becomes a bool
+// array. Literal values of the file, like "Hello, world", are not split.
+// Spaces around elements are trimmed, and elements are parsed as numbers
+// if they all are numbers, as booleans if they all are booleans, and kept
+// as strings otherwise.
+func WithCSVArrays() Option {
+	return func(o *options) {
+		o.csvArrays = true
+	}
+}
+
+// WithAllowEmpty makes Load return an empty Config instead of an error
+// when the configuration file is empty or only holds whitespace.
+func WithAllowEmpty() Option {
+	return func(o *options) {
+		o.allowEmpty = true
+	}
+}
+
+// WithMaxFileSize makes Load return an error, before parsing, if the
+// configuration file is larger than bytes.
+func WithMaxFileSize(bytes int64) Option {
+	return func(o *options) {
+		o.maxSize = bytes
+	}
+}
+
+// WithRootKey makes Load remove the root segment from the keys of all the
+// parameters, for files that wrap the whole configuration in a single
+// object: with root "app", "app.server.port" becomes "server.port". An
+// error is returned if some parameters are not under root.
+func WithRootKey(root string) Option {
+	return func(o *options) {
+		o.rootKey = root
+	}
+}
+
+// WithStyleInsensitiveKeys makes parameter keys insensitive to case and to
+// the camelCase, snake_case and kebab-case styles: "maxConnections",
+// "max_connections" and "max-connections" all designate the same
+// parameter. Keys are stored in lowercase without underscores and dashes,
+// which is the form Get, Has and Sub fall back to when their path is not
+// found. Load returns an error if two keys of the same object have the
+// same canonical form.
+func WithStyleInsensitiveKeys() Option {
+	return func(o *options) {
+		o.styleless = true
+	}
+}
+
+// WithCaseInsensitiveKeys makes parameter keys case-insensitive:
+// "Server.Port" and "server.port" designate the same parameter. Keys are
+// stored in lowercase, which is the form Get, Has and Sub fall back to when
+// their path is not found. Load returns an error if two keys of the same
+// object only differ by their case. See WithStyleInsensitiveKeys to also
+// ignore underscores and dashes.
+func WithCaseInsensitiveKeys() Option {
+	return func(o *options) {
+		o.caseless = true
+	}
+}
+
+// WithDelimiter makes Load join the key segments of parameters with d
+// instead of ".", for files whose keys contain dots: with "/", the port of
+// {"server": {"port": 80}} is "server/port", and the key
+// "feature.v2.enabled" is left intact. d is also used for array index keys
+// like "array/0". d cannot contain letters or digits.
+//
+// Get and the getters look keys up as they are, but the methods that split
+// keys into segments, like Sub, Set or Unmarshal, use "." unless they are
+// given this option too.
+func WithDelimiter(d string) Option {
+	return func(o *options) {
+		o.delimiter = d
+	}
+}
+
+// WithHTTPClient makes LoadURL send its request with client instead of
+// http.DefaultClient, for instance to set a timeout or authentication.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithArrayMergeAppend makes LoadAll and LoadDir append the arrays of a file to the
+// arrays of the same key loaded from the previous files, instead of
+// replacing them. Arrays are only appended to arrays of the same type.
+func WithArrayMergeAppend() Option {
+	return func(o *options) {
+		o.appendArrays = true
+	}
+}
+
 // Config is a map of parameters. Each key corresponds to the absolute path
 // of the parameter in the configuration file. Values are the raw value of
 // the parameter.
 type Config map[string]interface{}
 
+// Sensitive is the value of a parameter declared sensitive by the schema
+// of LoadWithSchema. The getters return the wrapped Value, while String,
+// ToEnv and the fmt package print a mask instead. Ranging over the Config,
+// or using Filter, WalkLeaves or OrderedSub, gives the Sensitive value
+// itself.
+type Sensitive struct {
+	Value interface{}
+}
+
+// String returns a mask instead of the wrapped value.
+func (s Sensitive) String() string {
+	return redacted
+}
+
+// OnLoad, if set, is called after each successful Load, LoadFromBytes or
+// LoadFromReader with the name of the file and the loaded Config, for
+// instance to audit the configurations in use. filename is "-" for the
+// standard input and empty for LoadFromBytes and LoadFromReader.
+var OnLoad func(filename string, c Config)
+
 // Load loads a configuration file and returns a Config object, or an error
 // if file could not be read or unmarshalled, or if the file doesn't exist.
-func Load(filename string) (Config, error) {
-	blob, err := readFile(filename)
+// If filename is "-", the configuration is read from the standard input and
+// its format is detected from its content.
+// Options can be provided to alter the loading behaviour.
+func Load(filename string, opts ...Option) (Config, error) {
+	o := newOptions(opts)
+	blob, format, err := o.read(filename)
 	if err != nil {
 		return Config{}, err
 	}
-	var raw interface{}
-	err = unmarshal(path.Ext(filename), blob, &raw)
+	cnf, err := o.load(blob, format)
+	if err == nil && OnLoad != nil {
+		OnLoad(filename, cnf)
+	}
+	return cnf, err
+}
+
+// LoadOrdered loads a configuration file like Load, and also returns the
+// keys of its parameters in declaration order, which can be given to
+// OrderedSub. Only YAML files preserve the order of their keys: for other
+// formats, like JSON whose objects have no order, the keys are sorted.
+func LoadOrdered(filename string, opts ...Option) (Config, []string, error) {
+	o := newOptions(opts)
+	o.keyOrder = true
+	blob, format, err := o.read(filename)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	cnf, err := o.load(blob, format)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if OnLoad != nil {
+		OnLoad(filename, cnf)
+	}
+	if o.order == nil {
+		return cnf, cnf.Keys(), nil
+	}
+	return cnf, o.order, nil
+}
+
+// LoadStrict loads a configuration file like Load, but returns an error if
+// the file has duplicate keys, which Load merges or lets the last one win,
+// or parameters that are not in allowed. A parameter is allowed if its key
+// or the key of one of its parents is in allowed, so "server" allows all
+// the parameters of the server object. The error lists all the offending
+// keys. Duplicate keys are detected in JSON and YAML files.
+func LoadStrict(filename string, allowed []string, opts ...Option) (Config, error) {
+	strict := func(o *options) {
+		o.strict = true
+	}
+	cnf, err := Load(filename, append(opts[:len(opts):len(opts)], strict)...)
+	if err != nil {
+		return Config{}, err
+	}
+	isAllowed := make(map[string]bool, len(allowed))
+	for _, k := range allowed {
+		isAllowed[k] = true
+	}
+	d := newOptions(opts).delim()
+	var unknown []string
+	for _, k := range cnf.Keys() {
+		if cnf.isArrayIndex(k) {
+			continue
+		}
+		parent := k
+		for !isAllowed[parent] && strings.Contains(parent, d) {
+			parent = parent[:strings.LastIndex(parent, d)]
+		}
+		if !isAllowed[parent] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		return Config{}, errors.New("Configuration has unknown parameters: " + strings.Join(unknown, ", "))
+	}
+	return cnf, nil
+}
+
+// MustLoad is like Load but panics if the configuration cannot be loaded.
+// It is intended for programs that cannot proceed without their
+// configuration, typically when initializing package-level variables.
+func MustLoad(filename string, opts ...Option) Config {
+	cnf, err := Load(filename, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return cnf
+}
+
+// LoadFromBytes loads a configuration from data, for instance generated
+// in memory, and returns a Config object like Load does. Since there is
+// no file name, format is the file name extension of the configuration
+// format, like ".json" or ".yaml".
+func LoadFromBytes(data []byte, format string, opts ...Option) (Config, error) {
+	cnf, err := newOptions(opts).load(data, format)
+	if err == nil && OnLoad != nil {
+		OnLoad("", cnf)
+	}
+	return cnf, err
+}
+
+// LoadFromReader loads a configuration from r, for instance an HTTP
+// response body, and returns a Config object like Load does. Since there
+// is no file name, format is the file name extension of the configuration
+// format, like ".json" or ".yaml".
+func LoadFromReader(r io.Reader, format string, opts ...Option) (Config, error) {
+	blob, err := newOptions(opts).readAll(r)
+	if err != nil {
+		return Config{}, err
+	}
+	return LoadFromBytes(blob, format, opts...)
+}
+
+// LoadURL loads a configuration from url with an HTTP GET request and
+// returns a Config object like Load does. The format is given by the file
+// name extension of the URL path, or else by the Content-Type header of
+// the response, or else guessed from the content like for the standard
+// input. An error including the status is returned if the response status
+// is not 200 OK. The request is sent with http.DefaultClient, unless
+// another client is set with WithHTTPClient.
+func LoadURL(url string, opts ...Option) (Config, error) {
+	return LoadURLContext(context.Background(), url, opts...)
+}
+
+// LoadURLContext is like LoadURL, but the request is canceled when ctx is
+// done.
+func LoadURLContext(ctx context.Context, url string, opts ...Option) (Config, error) {
+	o := newOptions(opts)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return Config{}, err
+	}
+	client := o.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return Config{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Config{}, errors.New("Could not load " + url + ": " + resp.Status)
+	}
+	blob, err := o.readAll(resp.Body)
+	if err != nil {
+		return Config{}, err
+	}
+	format := path.Ext(req.URL.Path)
+	if format == "" {
+		format = contentTypeFormat(resp.Header.Get("Content-Type"))
+	}
+	if format == "" {
+		format = sniffFormat(blob)
+	}
+	cnf, err := o.load(blob, format)
+	if err == nil && OnLoad != nil {
+		OnLoad(url, cnf)
+	}
+	return cnf, err
+}
+
+// LoadWithDotenv loads a configuration file like Load, after loading the
+// .env files found in the directory of the file and in the working
+// directory into the environment, so that the environment variables they
+// define can be referenced by the configuration. Variables already set in
+// the environment take precedence over .env entries, and the .env file of
+// the configuration directory takes precedence over the one of the working
+// directory.
+func LoadWithDotenv(filename string, opts ...Option) (Config, error) {
+	dirs := []string{"."}
+	if found, err := findFile(filename); err == nil {
+		dirs = []string{filepath.Dir(found), "."}
+	}
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil || seen[abs] {
+			continue
+		}
+		seen[abs] = true
+		if err := loadDotenv(filepath.Join(abs, ".env")); err != nil {
+			return Config{}, err
+		}
+	}
+	return Load(filename, opts...)
+}
+
+// EditFile replaces the value of parameter key in the YAML configuration
+// file filename by value, which must be a string, a number or a boolean.
+// Only the text of the value is rewritten, so the comments and the layout
+// of the file are preserved. Strings with line breaks are written as
+// double-quoted scalars to stay on one line. An error is returned if the
+// parameter doesn't exist or is not a single-line scalar.
+func EditFile(filename, key string, value interface{}) error {
+	if ext := path.Ext(filename); ext != ".yml" && ext != ".yaml" {
+		return errors.New("EditFile only supports YAML files")
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return errors.New("Value of parameter " + key + " is not a scalar")
+	}
+	found, err := findFile(filename)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(found)
+	if err != nil {
+		return err
+	}
+	blob, err := ioutil.ReadFile(found)
+	if err != nil {
+		return err
+	}
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(blob, &doc); err != nil {
+		return err
+	}
+	node := &doc
+	for _, part := range strings.Split(key, ".") {
+		if node = yamlChild(node, part); node == nil {
+			return errors.New("Parameter " + key + " not found")
+		}
+	}
+	if node.Kind != yamlv3.ScalarNode {
+		return errors.New("Parameter " + key + " is not a scalar")
+	}
+	lines := strings.SplitAfter(string(blob), "\n")
+	line := lines[node.Line-1]
+	start := 0
+	for col := 1; col < node.Column && start < len(line); col++ {
+		_, size := utf8.DecodeRuneInString(line[start:])
+		start += size
+	}
+	end := scalarEnd(line[start:], node)
+	if end < 0 {
+		return errors.New("Parameter " + key + " is not a single-line scalar")
+	}
+	text, err := yamlv3.Marshal(value)
+	if err != nil {
+		return err
+	}
+	scalar := strings.TrimSuffix(string(text), "\n")
+	if strings.Contains(scalar, "\n") {
+		// strings with line breaks are marshaled as block scalars, which
+		// span several lines; a double-quoted scalar keeps them on one.
+		scalar = strconv.Quote(reflect.ValueOf(value).String())
+	}
+	lines[node.Line-1] = line[:start] + scalar + line[start+end:]
+	return ioutil.WriteFile(found, []byte(strings.Join(lines, "")), fi.Mode())
+}
+
+// watchDebounce is the delay without change after which Watch reloads the
+// file, so that successive writes trigger a single reload.
+var watchDebounce = 100 * time.Millisecond
+
+// Watch loads the configuration file filename with Load each time it
+// changes, and calls onChange with the new Config, or with the error if
+// the file could not be loaded. Successive changes are debounced. The
+// directory of the file is watched rather than the file itself, so that
+// editors replacing the file by renaming another one are supported.
+// The returned stop function stops watching the file. onChange is not
+// called after stop returns, unless it is already running.
+func Watch(filename string, onChange func(Config, error), opts ...Option) (stop func(), err error) {
+	found, err := findFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(found)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		var reload <-chan time.Time
+		for {
+			select {
+			case <-done:
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) == filepath.Clean(found) && ev.Op != fsnotify.Chmod {
+					reload = time.After(watchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onChange(Config{}, err)
+			case <-reload:
+				reload = nil
+				select {
+				case <-done:
+					return
+				default:
+				}
+				onChange(Load(found, opts...))
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
+
+// LoadArray loads a configuration file whose top-level value is an array
+// of objects, and returns one Config per element. Elements are flattened
+// independently, so their keys are not prefixed with their index.
+// An error is returned if the top-level value is not an array or if one
+// of its elements is not an object.
+func LoadArray(filename string, opts ...Option) ([]Config, error) {
+	o := newOptions(opts)
+	blob, format, err := o.read(filename)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := o.parse(blob, format)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.New("Configuration is not an array")
+	}
+	cnfs := make([]Config, len(arr))
+	for i, elem := range arr {
+		switch elem.(type) {
+		case map[string]interface{}, map[interface{}]interface{}:
+		default:
+			return nil, errors.New("Element " + strconv.Itoa(i) + " of configuration is not an object")
+		}
+		if cnfs[i], err = o.build(elem); err != nil {
+			return nil, err
+		}
+	}
+	return cnfs, nil
+}
+
+// LoadAll loads several configuration files with Load and merges them in
+// order, so that the parameters of a file override the ones of the
+// previous files. Arrays are replaced as a whole, unless
+// WithArrayMergeAppend is given.
+func LoadAll(filenames []string, opts ...Option) (Config, error) {
+	o := newOptions(opts)
+	cnf := make(Config)
+	for _, filename := range filenames {
+		c, err := Load(filename, opts...)
+		if err != nil {
+			return Config{}, err
+		}
+		cnf.merge(c, o.appendArrays, o.delim())
+	}
+	return cnf, nil
+}
+
+// LoadWithEmbeddedDefault loads the default configuration defaultBytes,
+// typically embedded in the program, whose format is the file name
+// extension defaultFormat. The configuration file userFile, if it exists,
+// is then loaded and merged like with LoadAll, so that its parameters
+// override the defaults.
+func LoadWithEmbeddedDefault(defaultBytes []byte, defaultFormat, userFile string, opts ...Option) (Config, error) {
+	o := newOptions(opts)
+	cnf, err := LoadFromBytes(defaultBytes, defaultFormat, opts...)
+	if err != nil {
+		return Config{}, err
+	}
+	if _, err := findFile(userFile); os.IsNotExist(err) {
+		return cnf, nil
+	}
+	c, err := Load(userFile, opts...)
+	if err != nil {
+		return Config{}, err
+	}
+	cnf.merge(c, o.appendArrays, o.delim())
+	return cnf, nil
+}
+
+// LoadConcat loads each reader of readers with LoadFromReader, in the
+// format of the same index of formats, and merges them in order like
+// LoadAll. An error is returned if readers and formats don't have the same
+// length.
+func LoadConcat(readers []io.Reader, formats []string, opts ...Option) (Config, error) {
+	if len(readers) != len(formats) {
+		return Config{}, errors.New("Number of readers and formats differ")
+	}
+	o := newOptions(opts)
+	cnf := make(Config)
+	for i, r := range readers {
+		c, err := LoadFromReader(r, formats[i], opts...)
+		if err != nil {
+			return Config{}, err
+		}
+		cnf.merge(c, o.appendArrays, o.delim())
+	}
+	return cnf, nil
+}
+
+// LoadLayered loads a configuration in layers of increasing precedence:
+// the defaults, then the configuration file, then the environment. A
+// parameter is overridden by the environment variable named after its key
+// like with ToEnv: with envPrefix "APP_", "server.port" is overridden by
+// APP_SERVER_PORT. Values from the environment are parsed if the parameter
+// is a number or a boolean, and stored as strings otherwise. The keys of
+// defaults are folded like the ones of the file by WithCaseInsensitiveKeys
+// and WithStyleInsensitiveKeys.
+func LoadLayered(filename string, defaults Config, envPrefix string, opts ...Option) (Config, error) {
+	o := newOptions(opts)
+	c, err := Load(filename, opts...)
+	if err != nil {
+		return Config{}, err
+	}
+	cnf := make(Config, len(defaults))
+	for k, v := range defaults.deepCopy() {
+		cnf[foldKey(k, o.styleless, o.caseless)] = v
+	}
+	cnf.merge(c, false, o.delim())
+	cnf.BindEnv(envPrefix)
+	return cnf, nil
+}
+
+// LoadDir loads the configuration files of directory dir, in lexical
+// order, and merges them like LoadAll. Files whose format is not supported
+// are skipped.
+func LoadDir(dir string, opts ...Option) (Config, error) {
+	return LoadDirContext(context.Background(), dir, opts...)
+}
+
+// LoadDirContext is like LoadDir, but aborts as soon as ctx is done. The
+// context is checked before each file is loaded. Loading fails fast: on
+// the first error or cancellation, no partial Config is returned and the
+// error, or ctx.Err(), is returned.
+func LoadDirContext(ctx context.Context, dir string, opts ...Option) (Config, error) {
+	o := newOptions(opts)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return Config{}, err
+	}
+	cnf := make(Config)
+	for _, fi := range files {
+		if fi.IsDir() || !isSupportedFormat(path.Ext(fi.Name())) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return Config{}, err
+		}
+		c, err := Load(filepath.Join(dir, fi.Name()), opts...)
+		if err != nil {
+			return Config{}, err
+		}
+		cnf.merge(c, o.appendArrays, o.delim())
+	}
+	return cnf, nil
+}
+
+// LoadWithSchema loads a configuration file like Load, then coerces its
+// parameters to the types declared in schemaFile. The schema is a JSON
+// object mapping parameter keys to one of the types "string", "int",
+// "float", "bool" or "duration", for instance {"paramInt": "int"}. String
+// values are parsed, which is useful for values set with environment
+// variables. An error is returned if a value cannot be converted to its
+// declared type. Parameters missing from the configuration, or null, are
+// ignored.
+//
+// A parameter can also be declared with an object, to mark it as
+// sensitive: {"password": {"type": "string", "sensitive": true}}. The
+// values of sensitive parameters, and of their children, are wrapped in
+// Sensitive, so that String and ToEnv mask them.
+func LoadWithSchema(configFile, schemaFile string, opts ...Option) (Config, error) {
+	blob, err := readFile(schemaFile)
+	if err != nil {
+		return Config{}, err
+	}
+	var schema map[string]schemaEntry
+	if err := json.Unmarshal(blob, &schema); err != nil {
+		return Config{}, err
+	}
+	cnf, err := Load(configFile, opts...)
+	if err != nil {
+		return Config{}, err
+	}
+	for k, entry := range schema {
+		v := cnf[k]
+		if v == nil || entry.Type == "" {
+			continue
+		}
+		if cnf[k], err = coerce(v, entry.Type); err != nil {
+			return Config{}, errors.New("Parameter " + k + ": " + err.Error())
+		}
+	}
+	d := newOptions(opts).delim()
+	for k, entry := range schema {
+		if !entry.Sensitive {
+			continue
+		}
+		for key, v := range cnf {
+			if key == k || strings.HasPrefix(key, k+d) {
+				cnf[key] = Sensitive{Value: v}
+			}
+		}
+	}
+	return cnf, nil
+}
+
+// LoadValidated loads a configuration file like Load, after validating the
+// parsed document against the JSON Schema in schemaFile. Environment
+// variables are substituted before validation. If the document is invalid,
+// the error lists the location and the message of each violation.
+func LoadValidated(configFile, schemaFile string, opts ...Option) (Config, error) {
+	blob, err := readFile(schemaFile)
+	if err != nil {
+		return Config{}, err
+	}
+	schema, err := jsonschema.CompileString(schemaFile, string(blob))
 	if err != nil {
 		return Config{}, err
 	}
-	return flatten(raw), nil
+	validate := func(o *options) {
+		o.validate = func(tree interface{}) error {
+			err := schema.Validate(tree)
+			if ve, ok := err.(*jsonschema.ValidationError); ok {
+				return errors.New("Configuration is invalid: " + strings.Join(validationMessages(ve), "; "))
+			}
+			return err
+		}
+	}
+	return Load(configFile, append(opts[:len(opts):len(opts)], validate)...)
+}
+
+// Assemble merges several configuration fragments into one Config. The
+// parameters of each fragment are namespaced under the fragment name, so
+// that the parameter "param" of fragment "plugin" becomes "plugin.param".
+// Names are joined with the delimiter given by WithDelimiter, which should
+// be the one the fragments were loaded with.
+func Assemble(fragments map[string]Config, opts ...Option) Config {
+	d := newOptions(opts).delim()
+	fields := make(Config)
+	for name, fragment := range fragments {
+		for k, v := range fragment {
+			fields[name+d+k] = v
+		}
+	}
+	return fields
 }
 
 // Get gets value of parameter p. p should be the absolute path to the parameter.
 // Example: { "param1": { "param2": 3.14 } }; to access param2, p should be
 // "param1.param2".
 func (c *Config) Get(p string) interface{} {
-	return (*c)[p]
+	v, _ := c.lookup(p)
+	return v
+}
+
+// Has reports whether parameter p exists. p should be the absolute path
+// to the parameter, like with Get. Parameters declared with a null value
+// are stored with a nil value, so Get returns nil for them, but Has returns
+// true, which tells them apart from missing parameters. The other getters
+// consider null parameters as missing.
+func (c *Config) Has(p string) bool {
+	_, ok := c.lookup(p)
+	return ok
+}
+
+// lookup returns the value of parameter p and whether it exists. If p is
+// not found, it is looked up again in lowercase, then in its canonical
+// form, which are how WithCaseInsensitiveKeys and WithStyleInsensitiveKeys
+// store keys. Sensitive values are unwrapped.
+func (c *Config) lookup(p string) (interface{}, bool) {
+	v, ok := (*c)[p]
+	if !ok {
+		v, ok = (*c)[strings.ToLower(p)]
+	}
+	if !ok {
+		v, ok = (*c)[canonicalKey(p)]
+	}
+	return unwrap(v), ok
+}
+
+// GetFirst gets the lexically first parameter under patternPrefix, and
+// returns its key and its value. It is useful when the name of the
+// children of patternPrefix is not known in advance. For instance, with
+// "backends.foo.host" and "backends.bar.host", GetFirst("backends")
+// returns "backends.bar.host". If no parameter is found, key is empty.
+// The key segments are delimited by ".", or by the delimiter given by
+// WithDelimiter.
+func (c *Config) GetFirst(patternPrefix string, opts ...Option) (key string, value interface{}) {
+	d := newOptions(opts).delim()
+	for k, v := range *c {
+		if !strings.HasPrefix(k, patternPrefix+d) {
+			continue
+		}
+		if key == "" || k < key {
+			key, value = k, v
+		}
+	}
+	return key, unwrap(value)
 }
 
 // GetString gets string value of parameter p.
 // If parameter is a number, the number is converted to a string.
 // If parameter is a boolean, the string will be "true" or "false".
 func (c *Config) GetString(p string) (s string) {
-	switch v := c.Get(p).(type) {
+	s, _ = toString(c.Get(p))
+	return s
+}
+
+// GetStringE is like GetString but returns an error if parameter p is
+// missing or null, or cannot be converted to a string.
+func (c *Config) GetStringE(p string) (string, error) {
+	v := c.Get(p)
+	if v == nil {
+		return "", &ParameterError{Key: p, Err: ErrMissingParameter}
+	}
+	s, ok := toString(v)
+	if !ok {
+		return "", &ParameterError{Key: p, Type: "string", Err: ErrInvalidValue}
+	}
+	return s, nil
+}
+
+// toString converts parameter value v to a string as GetString does, and
+// reports whether v could be converted.
+func toString(v interface{}) (s string, ok bool) {
+	switch v := v.(type) {
 	case string:
 		s = v
 	case float64:
 		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		s = v.String()
 	case bool:
 		s = strconv.FormatBool(v)
 	case []string:
@@ -93,259 +955,2956 @@ func (c *Config) GetString(p string) (s string) {
 			arr[i] = strconv.FormatBool(k)
 		}
 		s = strings.Join(arr, ",")
+	default:
+		return "", false
+	}
+	return s, true
+}
+
+// GetStringFloat gets string value of numeric parameter p, formatted with
+// exactly prec decimal places. The number is converted as with GetFloat.
+func (c *Config) GetStringFloat(p string, prec int) string {
+	return strconv.FormatFloat(c.GetFloat(p), 'f', prec, 64)
+}
+
+// GetFloat gets float value of parameter p.
+// If parameter is a boolean, the number will be 1.0 if true, 0.0 if false.
+func (c *Config) GetFloat(p string) (f float64) {
+	f, _ = toFloat(c.Get(p))
+	return f
+}
+
+// GetFloatE is like GetFloat but returns an error if parameter p is missing
+// or null, or cannot be converted to a number.
+func (c *Config) GetFloatE(p string) (float64, error) {
+	v := c.Get(p)
+	if v == nil {
+		return 0, &ParameterError{Key: p, Err: ErrMissingParameter}
+	}
+	f, ok := toFloat(v)
+	if !ok {
+		return 0, &ParameterError{Key: p, Type: "float", Err: ErrInvalidValue}
+	}
+	return f, nil
+}
+
+// toFloat converts parameter value v to a number as GetFloat does, and
+// reports whether v could be converted. The first element of arrays is
+// used.
+func toFloat(v interface{}) (f float64, ok bool) {
+	switch v := v.(type) {
+	case float64:
+		f = v
+	case json.Number:
+		f, _ = v.Float64()
+	case bool:
+		if v {
+			f = 1.0
+		}
+	case []float64:
+		if len(v) == 0 {
+			return 0, false
+		}
+		f = v[0]
+	case []bool:
+		if len(v) == 0 {
+			return 0, false
+		}
+		if v[0] {
+			f = 1.0
+		}
+	default:
+		return 0, false
+	}
+	return f, true
+}
+
+// GetFloatLocale gets float value of parameter p, parsing strings that use
+// decimalSep as decimal separator, like "42,1" with decimalSep ",". The
+// other separator among "." and ",", spaces and apostrophes are considered
+// thousands separators and are removed, so "1.234,56" is 1234.56. Numbers
+// are returned as is, and an error is returned if the string is not a
+// number or if decimalSep is empty.
+func (c *Config) GetFloatLocale(p, decimalSep string) (float64, error) {
+	if decimalSep == "" {
+		return 0, errors.New("Decimal separator cannot be empty")
+	}
+	v, ok := c.Get(p).(string)
+	if !ok {
+		return c.GetFloat(p), nil
+	}
+	parts := strings.Split(v, decimalSep)
+	for i, part := range parts {
+		parts[i] = strings.Map(func(r rune) rune {
+			switch r {
+			case '.', ',', ' ', '\'', '\u00a0':
+				return -1
+			}
+			return r
+		}, part)
+	}
+	f, err := strconv.ParseFloat(strings.Join(parts, "."), 64)
+	if err != nil {
+		return 0, errors.New("Value " + v + " is not a number")
+	}
+	return f, nil
+}
+
+// GetInt gets int value of parameter p.
+func (c *Config) GetInt(p string) int {
+	return int(c.GetInt64(p))
+}
+
+// GetIntE gets int value of parameter p. Unlike GetInt, it returns an error
+// if the parameter is missing, if it cannot be converted to a number, or if
+// the value doesn't fit in the platform int instead of a wrapped number.
+func (c *Config) GetIntE(p string) (int, error) {
+	f, err := c.GetFloatE(p)
+	if errors.Is(err, ErrInvalidValue) {
+		return 0, &ParameterError{Key: p, Type: "int", Err: ErrInvalidValue}
+	} else if err != nil {
+		return 0, err
+	}
+	if n, ok := c.Get(p).(json.Number); ok {
+		if i, err := strconv.ParseInt(n.String(), 10, intSize); err == nil {
+			return int(i), nil
+		}
+	}
+	i, err := floatToInt(f, intSize)
+	return int(i), err
+}
+
+// GetInt64 gets int64 value of parameter p. Unlike GetInt, its size
+// doesn't depend on the platform, which makes it suitable for large values
+// like byte counts or timestamps.
+func (c *Config) GetInt64(p string) int64 {
+	if n, ok := c.Get(p).(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+	}
+	return int64(c.GetFloat(p))
+}
+
+// GetUint gets uint64 value of parameter p. Negative numbers are clamped
+// to 0.
+func (c *Config) GetUint(p string) uint64 {
+	if n, ok := c.Get(p).(json.Number); ok {
+		if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+			return u
+		}
+	}
+	f := c.GetFloat(p)
+	if f < 0 {
+		return 0
+	}
+	return uint64(f)
+}
+
+// GetDuration gets duration value of parameter p. p can have
+// suffixes like s, ms, h, etc. In fact the same as standard time.ParseDuration().
+// Spaces and commas between units are ignored, so "1h 30m" and "1h,30m"
+// are the same as "1h30m".
+func (c *Config) GetDuration(p string) (d time.Duration) {
+	d, _ = parseDuration(c.GetString(p))
+	return d
+}
+
+// GetDurationE is like GetDuration but returns an error if parameter p is
+// missing or is not a valid duration.
+func (c *Config) GetDurationE(p string) (time.Duration, error) {
+	s, err := c.GetStringE(p)
+	if err != nil && !errors.Is(err, ErrInvalidValue) {
+		return 0, err
+	}
+	d, err := parseDuration(s)
+	if err != nil {
+		return 0, &ParameterError{Key: p, Type: "duration", Err: ErrInvalidValue, Cause: err}
+	}
+	return d, nil
+}
+
+// GetDurationDefault gets duration value of parameter p like GetDuration,
+// or def if parameter is missing or null. An invalid duration still gives
+// 0, use GetDurationE to detect it.
+func (c *Config) GetDurationDefault(p string, def time.Duration) time.Duration {
+	if c.Get(p) == nil {
+		return def
+	}
+	return c.GetDuration(p)
+}
+
+// GetBytes gets the byte count of size parameter p, like "256MB" or
+// "1.5GiB". Decimal units KB, MB, GB, TB and PB are powers of 1000 and
+// binary units KiB, MiB, GiB, TiB and PiB are powers of 1024. Units are
+// case-insensitive and can be separated from the number by spaces. Numbers
+// without unit are byte counts. 0 is returned if the value is invalid.
+func (c *Config) GetBytes(p string) int64 {
+	n, _ := c.GetBytesE(p)
+	return n
+}
+
+// GetBytesE is like GetBytes but returns an error if parameter p is
+// missing or is not a valid size.
+func (c *Config) GetBytesE(p string) (int64, error) {
+	s, err := c.GetStringE(p)
+	if err != nil && !errors.Is(err, ErrInvalidValue) {
+		return 0, err
+	}
+	n, err := parseBytes(s)
+	if err != nil {
+		return 0, &ParameterError{Key: p, Type: "bytes", Err: ErrInvalidValue, Cause: err}
+	}
+	return n, nil
+}
+
+// GetTime gets time value of parameter p, which should be an RFC 3339
+// timestamp like "2006-01-02T15:04:05Z07:00". The zero time is returned if
+// the value cannot be parsed.
+func (c *Config) GetTime(p string) time.Time {
+	t, _ := c.GetTimeE(p)
+	return t
+}
+
+// GetTimeFormat is like GetTime but parses the value with layout, as
+// defined by time.Parse.
+func (c *Config) GetTimeFormat(p, layout string) time.Time {
+	t, _ := c.getTime(p, layout)
+	return t
+}
+
+// GetTimeE is like GetTime but returns an error if parameter p is missing
+// or is not an RFC 3339 timestamp.
+func (c *Config) GetTimeE(p string) (time.Time, error) {
+	return c.getTime(p, time.RFC3339)
+}
+
+// getTime parses the value of parameter p with layout.
+func (c *Config) getTime(p, layout string) (time.Time, error) {
+	if c.Get(p) == nil {
+		return time.Time{}, &ParameterError{Key: p, Err: ErrMissingParameter}
+	}
+	t, err := time.Parse(layout, strings.TrimSpace(c.GetString(p)))
+	if err != nil {
+		return time.Time{}, &ParameterError{Key: p, Type: "time", Err: ErrInvalidValue, Cause: err}
+	}
+	return t, nil
+}
+
+// GetBool gets number value of parameter p.
+// If parameter is a number, the boolean will be true if parameter is not 0,
+// false otherwise.
+func (c *Config) GetBool(p string) (b bool) {
+	b, _ = toBool(c.Get(p))
+	return b
+}
+
+// GetBoolE is like GetBool but returns an error if parameter p is missing
+// or null, or cannot be converted to a boolean.
+func (c *Config) GetBoolE(p string) (bool, error) {
+	v := c.Get(p)
+	if v == nil {
+		return false, &ParameterError{Key: p, Err: ErrMissingParameter}
+	}
+	b, ok := toBool(v)
+	if !ok {
+		return false, &ParameterError{Key: p, Type: "bool", Err: ErrInvalidValue}
+	}
+	return b, nil
+}
+
+// toBool converts parameter value v to a boolean as GetBool does, and
+// reports whether v could be converted. The first element of arrays is
+// used.
+func toBool(v interface{}) (b bool, ok bool) {
+	switch v := v.(type) {
+	case bool:
+		b = v
+	case float64:
+		b = v != 0
+	case json.Number:
+		b = v.String() != "0"
+	case []bool:
+		if len(v) == 0 {
+			return false, false
+		}
+		b = v[0]
+	case []float64:
+		if len(v) == 0 {
+			return false, false
+		}
+		b = v[0] != 0
+	default:
+		return false, false
+	}
+	return b, true
+}
+
+// GetStringDefault gets string value of parameter p like GetString, or def
+// if parameter is missing or null.
+func (c *Config) GetStringDefault(p, def string) string {
+	if c.Get(p) == nil {
+		return def
+	}
+	return c.GetString(p)
+}
+
+// GetIntDefault gets int value of parameter p like GetInt, or def if
+// parameter is missing or null.
+func (c *Config) GetIntDefault(p string, def int) int {
+	if c.Get(p) == nil {
+		return def
+	}
+	return c.GetInt(p)
+}
+
+// GetFloatDefault gets float value of parameter p like GetFloat, or def if
+// parameter is missing or null.
+func (c *Config) GetFloatDefault(p string, def float64) float64 {
+	if c.Get(p) == nil {
+		return def
+	}
+	return c.GetFloat(p)
+}
+
+// GetBoolDefault gets bool value of parameter p like GetBool, or def if
+// parameter is missing or null.
+func (c *Config) GetBoolDefault(p string, def bool) bool {
+	if c.Get(p) == nil {
+		return def
+	}
+	return c.GetBool(p)
+}
+
+// GetStringArray gets a string slice from parameter p.
+// If parameter is null or missing, the slice is empty, like with the
+// other array getters.
+func (c *Config) GetStringArray(p string) (a []string) {
+	switch v := c.Get(p).(type) {
+	case []string:
+		a = v
+	case []float64:
+		arr := make([]string, len(v))
+		for i, k := range v {
+			arr[i], _ = toString(c.arrayElement(p, i, k))
+		}
+		a = arr
+	case []bool:
+		arr := make([]string, len(v))
+		for i, k := range v {
+			arr[i] = strconv.FormatBool(k)
+		}
+		a = arr
+	case string:
+		a = []string{v}
+	case float64:
+		a = []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case json.Number:
+		a = []string{v.String()}
+	case bool:
+		a = []string{strconv.FormatBool(v)}
+	}
+	return a
+}
+
+// GetFloatArray gets a float64 slice from parameter p.
+func (c *Config) GetFloatArray(p string) (a []float64) {
+	switch v := c.Get(p).(type) {
+	case []float64:
+		a = v
+	case []bool:
+		arr := make([]float64, len(v))
+		for i, k := range v {
+			if k {
+				arr[i] = 1.0
+			} else {
+				arr[i] = 0.0
+			}
+		}
+		a = arr
+	case float64:
+		a = []float64{v}
+	case json.Number:
+		f, _ := v.Float64()
+		a = []float64{f}
+	case bool:
+		if v {
+			a = []float64{1.0}
+		} else {
+			a = []float64{0.0}
+		}
+	}
+	return a
+}
+
+// GetIntArray gets a int slice from parameter p.
+func (c *Config) GetIntArray(p string) []int {
+	arr := c.GetFloatArray(p)
+	a := make([]int, len(arr))
+	for i, k := range arr {
+		a[i] = int(k)
+	}
+	// large integers are only exact as json.Number.
+	for i, v := range c.GetScalarOrArray(p) {
+		if n, ok := v.(json.Number); ok && i < len(a) {
+			k, _ := n.Int64()
+			a[i] = int(k)
+		}
+	}
+	return a
+}
+
+// arrayElement returns element i of array parameter p, whose value in the
+// array is v. Integers that a float64 cannot represent exactly are stored
+// as json.Number under the index key of the element, which is returned
+// instead.
+func (c *Config) arrayElement(p string, i int, v float64) interface{} {
+	if math.Abs(v) < maxExactInt {
+		return v
+	}
+	for k, n := range *c {
+		n, ok := unwrap(n).(json.Number)
+		if !ok {
+			continue
+		}
+		if base, j, ok := c.arrayIndex(k); ok && j == i && (base == p || base == strings.ToLower(p) || base == canonicalKey(p)) {
+			return n
+		}
+	}
+	return v
+}
+
+// GetIntArraySorted gets a int slice from parameter p, sorted in
+// ascending order. The stored array is left unchanged.
+func (c *Config) GetIntArraySorted(p string) []int {
+	a := c.GetIntArray(p)
+	sort.Ints(a)
+	return a
+}
+
+// GetDurationArray gets a duration slice from parameter p.
+func (c *Config) GetDurationArray(p string) []time.Duration {
+	arr := c.GetStringArray(p)
+	a := make([]time.Duration, len(arr))
+	for i, k := range arr {
+		a[i], _ = parseDuration(k)
+	}
+	return a
+}
+
+// GetBoolArray gets a bool slice from parameter p.
+func (c *Config) GetBoolArray(p string) (a []bool) {
+	switch v := c.Get(p).(type) {
+	case []bool:
+		a = v
+	case []float64:
+		arr := make([]bool, len(v))
+		for i, k := range v {
+			if k != 0 {
+				arr[i] = true
+			} else {
+				arr[i] = false
+			}
+		}
+		a = arr
+	case bool:
+		a = []bool{v}
+	case float64:
+		if v != 0 {
+			a = []bool{true}
+		} else {
+			a = []bool{false}
+		}
+	}
+	return a
+}
+
+// GetCSVArray gets a string slice from parameter p. If parameter is a
+// string, it is split on commas and spaces around elements are trimmed.
+// Native arrays take precedence over splitting: they are returned as with
+// GetStringArray and their elements are never split.
+func (c *Config) GetCSVArray(p string) []string {
+	return c.GetStringSliceDelim(p, ",")
+}
+
+// GetStringSliceDelim gets a string slice from parameter p. If parameter
+// is a string, it is split on sep and spaces around elements are trimmed;
+// an empty string gives an empty slice. Native arrays are returned as with
+// GetStringArray and their elements are never split.
+func (c *Config) GetStringSliceDelim(p, sep string) []string {
+	v, ok := c.Get(p).(string)
+	if !ok {
+		return c.GetStringArray(p)
+	}
+	if strings.TrimSpace(v) == "" {
+		return []string{}
+	}
+	a := strings.Split(v, sep)
+	for i, k := range a {
+		a[i] = strings.TrimSpace(k)
+	}
+	return a
+}
+
+// GetStringArrayReversed gets a string slice from parameter p, in reverse
+// order. The stored array is left unchanged.
+func (c *Config) GetStringArrayReversed(p string) []string {
+	arr := c.GetStringArray(p)
+	if arr == nil {
+		return nil
+	}
+	a := make([]string, len(arr))
+	for i, k := range arr {
+		a[len(arr)-1-i] = k
+	}
+	return a
+}
+
+// GetStringArrayTrimPrefix gets a string slice from parameter p, with
+// prefix removed from the elements starting with it.
+func (c *Config) GetStringArrayTrimPrefix(p, prefix string) []string {
+	arr := c.GetStringArray(p)
+	if arr == nil {
+		return nil
+	}
+	a := make([]string, len(arr))
+	for i, k := range arr {
+		a[i] = strings.TrimPrefix(k, prefix)
+	}
+	return a
+}
+
+// LookupStringAt gets the element of index i of the string slice from
+// parameter p, and reports whether it exists.
+func (c *Config) LookupStringAt(p string, i int) (string, bool) {
+	a := c.GetStringArray(p)
+	if i < 0 || i >= len(a) {
+		return "", false
+	}
+	return a[i], true
+}
+
+// GetStringArraySlice gets the elements of index start to end, excluded,
+// of the string slice from parameter p. The range is clamped to the bounds
+// of the slice, and the result is empty if it is invalid.
+func (c *Config) GetStringArraySlice(p string, start, end int) []string {
+	a := c.GetStringArray(p)
+	if start < 0 {
+		start = 0
+	}
+	if end > len(a) {
+		end = len(a)
+	}
+	if start >= end {
+		return []string{}
+	}
+	return append([]string{}, a[start:end]...)
+}
+
+// GroupStringArray groups the elements of the string slice from parameter
+// p by the result of keyFn. Elements keep their order within each group.
+func (c *Config) GroupStringArray(p string, keyFn func(string) string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, k := range c.GetStringArray(p) {
+		key := keyFn(k)
+		groups[key] = append(groups[key], k)
+	}
+	return groups
+}
+
+// CountDistinct returns the number of distinct elements of array
+// parameter p, compared as strings.
+func (c *Config) CountDistinct(p string) int {
+	distinct := make(map[string]bool)
+	for _, k := range c.GetStringArray(p) {
+		distinct[k] = true
+	}
+	return len(distinct)
+}
+
+// SumFloatArray returns the sum of the elements of the float slice from
+// parameter p, or 0 if the slice is empty.
+func (c *Config) SumFloatArray(p string) (sum float64) {
+	for _, k := range c.GetFloatArray(p) {
+		sum += k
+	}
+	return sum
+}
+
+// MaxFloatArray returns the greatest element of the float slice from
+// parameter p, or 0 if the slice is empty.
+func (c *Config) MaxFloatArray(p string) float64 {
+	a := c.GetFloatArray(p)
+	if len(a) == 0 {
+		return 0
+	}
+	max := a[0]
+	for _, k := range a[1:] {
+		max = math.Max(max, k)
+	}
+	return max
+}
+
+// MinFloatArray returns the smallest element of the float slice from
+// parameter p, or 0 if the slice is empty.
+func (c *Config) MinFloatArray(p string) float64 {
+	a := c.GetFloatArray(p)
+	if len(a) == 0 {
+		return 0
+	}
+	min := a[0]
+	for _, k := range a[1:] {
+		min = math.Min(min, k)
+	}
+	return min
+}
+
+// GetStringArrayValidated gets a string slice from parameter p and calls
+// valid on each element. If some elements are invalid, an error listing
+// the index, the value and the validation error of each of them is
+// returned.
+func (c *Config) GetStringArrayValidated(p string, valid func(string) error) ([]string, error) {
+	a := c.GetStringArray(p)
+	var msgs []string
+	for i, k := range a {
+		if err := valid(k); err != nil {
+			msgs = append(msgs, "element "+strconv.Itoa(i)+" ("+strconv.Quote(k)+"): "+err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return nil, errors.New("Parameter " + p + " is invalid: " + strings.Join(msgs, "; "))
+	}
+	return a, nil
+}
+
+// GetEnumArray gets a string slice from parameter p, typically a list of
+// values in priority order, whose elements must all be in allowed. Elements
+// are compared to allowed values regardless of case and are returned as
+// spelled in allowed. If some elements are not allowed, an error listing
+// the index and the value of each of them is returned.
+func (c *Config) GetEnumArray(p string, allowed []string) ([]string, error) {
+	a := c.GetStringArray(p)
+	enum := make([]string, len(a))
+	var msgs []string
+	for i, k := range a {
+		found := false
+		for _, v := range allowed {
+			if strings.EqualFold(k, v) {
+				enum[i], found = v, true
+				break
+			}
+		}
+		if !found {
+			msgs = append(msgs, "element "+strconv.Itoa(i)+" ("+strconv.Quote(k)+") is not one of "+strings.Join(allowed, ", "))
+		}
+	}
+	if len(msgs) > 0 {
+		return nil, errors.New("Parameter " + p + " is invalid: " + strings.Join(msgs, "; "))
+	}
+	return enum, nil
+}
+
+// GetIPArray gets a slice of IP addresses from parameter p. If some
+// elements are not valid IPv4 or IPv6 addresses, an error listing the
+// index and the value of each of them is returned.
+func (c *Config) GetIPArray(p string) ([]net.IP, error) {
+	a := c.GetStringArray(p)
+	ips := make([]net.IP, len(a))
+	var msgs []string
+	for i, k := range a {
+		if ips[i] = net.ParseIP(strings.TrimSpace(k)); ips[i] == nil {
+			msgs = append(msgs, "element "+strconv.Itoa(i)+" ("+strconv.Quote(k)+") is not an IP address")
+		}
+	}
+	if len(msgs) > 0 {
+		return nil, errors.New("Parameter " + p + " is invalid: " + strings.Join(msgs, "; "))
+	}
+	return ips, nil
+}
+
+// GetStringArrayJSON gets a slice of the elements of array parameter p,
+// each encoded in JSON. Strings are quoted and escaped while numbers and
+// booleans are left as is, which makes elements unambiguous in logs.
+func (c *Config) GetStringArrayJSON(p string) []string {
+	arr := c.GetScalarOrArray(p)
+	if arr == nil {
+		return nil
+	}
+	a := make([]string, len(arr))
+	for i, k := range arr {
+		blob, _ := json.Marshal(k)
+		a[i] = string(blob)
+	}
+	return a
+}
+
+// GetStringArrayChunks gets a string slice from parameter p, split in
+// chunks of at most size elements. If size is not positive, the whole
+// slice is returned as one chunk.
+func (c *Config) GetStringArrayChunks(p string, size int) [][]string {
+	a := c.GetStringArray(p)
+	if len(a) == 0 {
+		return nil
+	}
+	if size <= 0 {
+		return [][]string{a}
+	}
+	chunks := make([][]string, 0, (len(a)+size-1)/size)
+	for size < len(a) {
+		chunks = append(chunks, a[:size:size])
+		a = a[size:]
+	}
+	return append(chunks, a)
+}
+
+// GetStringMap gets the object p as a map of its children, keyed by
+// their name relative to p. Nested objects are returned as nested maps
+// and arrays as slices, so the map has the structure of the object in the
+// configuration file. If p is not an object, the map is empty. The key
+// segments are delimited by ".", or by the delimiter given by
+// WithDelimiter.
+func (c *Config) GetStringMap(p string, opts ...Option) map[string]interface{} {
+	if m, ok := c.Get(p).(map[string]interface{}); ok {
+		return m
+	}
+	d := newOptions(opts).delim()
+	sub := c.sub(p, d)
+	return sub.unflatten(d)
+}
+
+// GetKVMap gets a map from parameter p, whose elements are in the form
+// key=value. Elements are split on the first =, and elements without =
+// are ignored.
+func (c *Config) GetKVMap(p string) map[string]string {
+	m := make(map[string]string)
+	for _, e := range c.GetStringArray(p) {
+		i := strings.Index(e, "=")
+		if i < 0 {
+			continue
+		}
+		m[e[:i]] = e[i+1:]
+	}
+	return m
+}
+
+// GetGlobMatches gets the paths of the files matching the glob pattern
+// held by parameter p, sorted. Relative patterns are resolved from dir,
+// typically the directory of the configuration file, or from the working
+// directory if dir is empty. An error is returned if the pattern is
+// malformed.
+func (c *Config) GetGlobMatches(p, dir string) ([]string, error) {
+	pattern := c.GetString(p)
+	if dir != "" && !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// GetScalarOrArray gets parameter p as a slice. If parameter is an array,
+// its elements are returned. If parameter is a scalar, a one-element slice
+// is returned. It normalizes parameters that can hold either one value or
+// a list of values.
+func (c *Config) GetScalarOrArray(p string) (a []interface{}) {
+	switch v := c.Get(p).(type) {
+	case []string:
+		a = make([]interface{}, len(v))
+		for i, k := range v {
+			a[i] = k
+		}
+	case []float64:
+		a = make([]interface{}, len(v))
+		for i, k := range v {
+			a[i] = c.arrayElement(p, i, k)
+		}
+	case []bool:
+		a = make([]interface{}, len(v))
+		for i, k := range v {
+			a[i] = k
+		}
+	case string, float64, json.Number, bool:
+		a = []interface{}{v}
+	}
+	return a
+}
+
+// GetInterfaceArray gets parameter p as a slice of interfaces, for APIs
+// taking a []interface{}. Like with GetScalarOrArray, a scalar gives a
+// one-element slice. Arrays of Configs loaded with WithoutFlatten are
+// returned as is.
+func (c *Config) GetInterfaceArray(p string) []interface{} {
+	if v, ok := c.Get(p).([]interface{}); ok {
+		return v
+	}
+	return c.GetScalarOrArray(p)
+}
+
+// ArrayLen returns the number of elements of array parameter p, or 0 if
+// it is not an array. Arrays of objects have no value under their own key,
+// only the parameters of their elements under index keys, like
+// "servers.0.host" and "servers.1.port", so their length is one more than
+// the highest index found under p. Elements of different shapes are
+// counted the same way. The elements of an array of objects can be read
+// with Sub:
+//
+//	for i := 0; i < cnf.ArrayLen("servers"); i++ {
+//		server := cnf.Sub("servers." + strconv.Itoa(i))
+//	}
+//
+// The key segments are delimited by ".", or by the delimiter given by
+// WithDelimiter.
+func (c *Config) ArrayLen(p string, opts ...Option) int {
+	switch v := c.Get(p).(type) {
+	case []string, []float64, []bool, []interface{}:
+		return reflect.ValueOf(v).Len()
+	}
+	d := newOptions(opts).delim()
+	p = c.storedKey(p, d)
+	n := 0
+	for k := range *c {
+		if !strings.HasPrefix(k, p+d) {
+			continue
+		}
+		index := strings.SplitN(k[len(p+d):], d, 2)[0]
+		if i, err := strconv.Atoi(index); err == nil && i >= n && strconv.Itoa(i) == index {
+			n = i + 1
+		}
+	}
+	return n
+}
+
+// ArrayContains reports whether the array parameter p contains value.
+// Elements are converted to strings the same way GetStringArray does.
+// It returns false if parameter is not an array.
+func (c *Config) ArrayContains(p string, value string) bool {
+	switch c.Get(p).(type) {
+	case []string, []float64, []bool:
+		for _, v := range c.GetStringArray(p) {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Set sets parameter p to v, replacing its previous value. v is stored
+// like Load stores values: numbers are converted to float64, durations to
+// strings like "1m30s", slices are stored along with the index keys of
+// their elements, like "p.0", and maps are flattened under p. The children
+// of p, like the index keys of a previous longer array or the parameters
+// of an object previously at p, are removed. A nil v removes p and its
+// children. If p was sensitive, v is too.
+// The key segments are delimited by ".", or by the delimiter given by
+// WithDelimiter. Options like WithCaseInsensitiveKeys fold p the way Load
+// folds keys.
+func (c *Config) Set(p string, v interface{}, opts ...Option) {
+	o := newOptions(opts)
+	d := o.delim()
+	p = c.storedKey(foldKey(p, o.styleless, o.caseless), d)
+	_, sensitive := (*c)[p].(Sensitive)
+	for k := range *c {
+		if k == p || strings.HasPrefix(k, p+d) {
+			delete(*c, k)
+		}
+	}
+	if v == nil {
+		return
+	}
+	// flatten only fails when expanding values, which raw disables.
+	fo := &options{raw: true, delimiter: d}
+	fields, _ := fo.flatten(canonicalValue(v), p+d)
+	for k, v := range fields {
+		if sensitive {
+			v = Sensitive{Value: v}
+		}
+		(*c)[strings.TrimRight(k, d)] = v
+	}
+}
+
+// Alias makes the deprecated parameter oldKey an alias of newKey, so that
+// renamed parameters can still be read with their old key. If newKey is an
+// object or an array, its children are aliased too. Parameters that are
+// explicitly set under oldKey take precedence over the ones of newKey.
+// Values are copied, so Alias should be called once the Config is loaded.
+// The key segments are delimited by ".", or by the delimiter given by
+// WithDelimiter.
+func (c *Config) Alias(oldKey, newKey string, opts ...Option) {
+	d := newOptions(opts).delim()
+	newKey = c.storedKey(newKey, d)
+	for k, v := range *c {
+		var alias string
+		if k == newKey {
+			alias = oldKey
+		} else if strings.HasPrefix(k, newKey+d) {
+			alias = oldKey + k[len(newKey):]
+		} else {
+			continue
+		}
+		if _, ok := (*c)[alias]; !ok {
+			(*c)[alias] = v
+		}
+	}
+}
+
+// Filter returns a new Config containing only the parameters for which
+// pred returns true.
+func (c *Config) Filter(pred func(key string, value interface{}) bool) Config {
+	fields := make(Config)
+	for k, v := range *c {
+		if pred(k, v) {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// Keys returns the sorted keys of all the parameters, including both the
+// keys of arrays and the index keys of their elements.
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(*c))
+	for k := range *c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// KeysByType returns the sorted keys of the parameters, grouped by the
+// name of the Go type of their value ("string", "float64", "bool",
+// "[]string", "[]float64" or "[]bool"), or "<nil>" for null parameters.
+// Sensitive values are grouped by the type of the value they wrap.
+func (c *Config) KeysByType() map[string][]string {
+	groups := make(map[string][]string)
+	for k, v := range *c {
+		typ := fmt.Sprintf("%T", unwrap(v))
+		groups[typ] = append(groups[typ], k)
+	}
+	for _, keys := range groups {
+		sort.Strings(keys)
+	}
+	return groups
+}
+
+// WalkLeaves calls fn for each leaf parameter, in key order. Leaves are
+// scalar parameters, including the elements of arrays under their index
+// keys. Unlike ranging over the Config, the aggregate keys holding whole
+// arrays are not visited, so each value is visited once.
+func (c *Config) WalkLeaves(fn func(key string, value interface{})) {
+	keys := make([]string, 0, len(*c))
+	for k, v := range *c {
+		switch unwrap(v).(type) {
+		case []string, []float64, []bool:
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fn(k, (*c)[k])
+	}
+}
+
+// Clone returns a deep copy of the Config. Arrays are copied too, so the
+// clone can be modified, or handed to another goroutine, without
+// affecting the original.
+func (c Config) Clone() Config {
+	return c.deepCopy()
+}
+
+// Snapshot returns a deep copy of the Config, which can later be given
+// to Restore to roll back changes.
+func (c *Config) Snapshot() Config {
+	return c.deepCopy()
+}
+
+// Restore replaces all the parameters of the Config by the ones of snap,
+// as taken by Snapshot. snap is copied, so it can be restored again.
+func (c *Config) Restore(snap Config) {
+	*c = snap.deepCopy()
+}
+
+// Merge copies the parameters of other into the Config, replacing the
+// existing ones, like LoadAll does with the files it loads. Since keys are
+// flattened, overriding "server.port" leaves "server.host" untouched. Null
+// parameters of other only replace missing ones.
+// Arrays are replaced as a whole: the index keys of the replaced array are
+// removed and the ones of the new array are set, so that "arr" and "arr.0"
+// stay consistent when the length changes.
+// The key segments are delimited by ".", or by the delimiter given by
+// WithDelimiter. WithArrayMergeAppend appends arrays instead.
+func (c *Config) Merge(other Config, opts ...Option) {
+	o := newOptions(opts)
+	c.merge(other, o.appendArrays, o.delim())
+}
+
+// Sub returns a new Config holding the parameters under prefix, with keys
+// relative to prefix: with prefix "logging", "logging.level" becomes
+// "level". Arrays are carried over along with their index keys, and
+// sensitive parameters stay sensitive. The Config is empty if no parameter
+// is under prefix. The key segments are delimited by ".", or by the
+// delimiter given by WithDelimiter.
+func (c *Config) Sub(prefix string, opts ...Option) Config {
+	return c.sub(prefix, newOptions(opts).delim())
+}
+
+// sub is Sub with the delimiter d.
+func (c *Config) sub(prefix, d string) Config {
+	prefix = c.storedKey(prefix, d) + d
+	sub := make(Config)
+	for k, v := range *c {
+		if strings.HasPrefix(k, prefix) {
+			sub[k[len(prefix):]] = v
+		}
+	}
+	return sub
+}
+
+// storedKey returns the key under which parameter p, or the parameters
+// under it, are stored: p itself, or else its lowercase or canonical form
+// like lookup. p is returned if none is found.
+func (c *Config) storedKey(p, d string) string {
+	for _, key := range []string{p, strings.ToLower(p), canonicalKey(p)} {
+		if _, ok := (*c)[key]; ok {
+			return key
+		}
+		for k := range *c {
+			if strings.HasPrefix(k, key+d) {
+				return key
+			}
+		}
+	}
+	return p
+}
+
+// KeyValue is a parameter key and its value.
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedSub returns the parameters under prefix, with keys relative to
+// prefix, in the order of the keys of order, as returned by LoadOrdered.
+// If prefix is empty, all parameters are returned. Parameters missing from
+// order, like the ones added after loading, come last and are sorted by
+// key. The key segments are delimited by ".", or by the delimiter given by
+// WithDelimiter.
+func (c *Config) OrderedSub(prefix string, order []string, opts ...Option) []KeyValue {
+	d := newOptions(opts).delim()
+	keys := make([]string, 0, len(*c))
+	seen := make(map[string]bool)
+	for _, k := range order {
+		if _, ok := (*c)[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	var others []string
+	for k := range *c {
+		if !seen[k] {
+			others = append(others, k)
+		}
+	}
+	sort.Strings(others)
+	keys = append(keys, others...)
+
+	sub := []KeyValue{}
+	for _, k := range keys {
+		if prefix != "" {
+			if !strings.HasPrefix(k, prefix+d) {
+				continue
+			}
+			sub = append(sub, KeyValue{Key: k[len(prefix)+len(d):], Value: (*c)[k]})
+		} else {
+			sub = append(sub, KeyValue{Key: k, Value: (*c)[k]})
+		}
+	}
+	return sub
+}
+
+// ToEnv converts the Config to a list of environment variables in the form
+// KEY=value, suitable for exec.Cmd.Env. Keys are prefixed with prefix,
+// upper-cased, and their dots are replaced by underscores. Arrays are
+// converted to comma separated values and their index keys are skipped.
+// The values of sensitive parameters are masked. The result is sorted by
+// key.
+func (c *Config) ToEnv(prefix string) []string {
+	env := make([]string, 0, len(*c))
+	for k := range *c {
+		if c.isArrayIndex(k) {
+			continue
+		}
+		env = append(env, envName(prefix, k)+"="+c.displayString(k))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// String returns the parameters of the Config, one key=value per line and
+// sorted by key. Arrays are converted to comma separated values and their
+// index keys are skipped. The values of sensitive parameters are masked.
+func (c Config) String() string {
+	lines := make([]string, 0, len(c))
+	for k := range c {
+		if c.isArrayIndex(k) {
+			continue
+		}
+		lines = append(lines, k+"="+c.displayString(k))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// Hash returns the SHA-256 hex digest of the parameters of the Config,
+// which can be used to detect changes or as a cache key. It only depends on
+// the keys and values: equal Configs have the same hash whatever the order
+// in which they were loaded, and numbers have the same hash whatever their
+// type, so that 42 and 42.0 are equal. Array index keys are skipped since
+// arrays are hashed as a whole.
+func (c Config) Hash() string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		if c.isArrayIndex(k) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		v, err := json.Marshal(canonicalValue(c[k]))
+		if err != nil {
+			v = []byte(fmt.Sprintf("%#v", c[k]))
+		}
+		fmt.Fprintf(h, "%q=%s\n", k, v)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalValue converts the numbers of v to float64, recursively in
+// slices and maps, so that equal numbers of different types are encoded
+// the same way. Integers that a float64 cannot represent exactly are
+// converted to json.Number instead, like Load stores them. Durations are
+// converted to strings.
+// Sensitive values are unwrapped.
+func canonicalValue(v interface{}) interface{} {
+	v = unwrap(v)
+	if d, ok := v.(time.Duration); ok {
+		return d.String()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intValue(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return json.Number(strconv.FormatUint(rv.Uint(), 10))
+		}
+		return intValue(int64(rv.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Slice, reflect.Array:
+		a := make([]interface{}, rv.Len())
+		for i := range a {
+			a[i] = canonicalValue(rv.Index(i).Interface())
+		}
+		return a
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[fmt.Sprint(k.Interface())] = canonicalValue(rv.MapIndex(k).Interface())
+		}
+		return m
+	}
+	return v
+}
+
+// Unmarshal decodes the Config into v, which should be a pointer to a
+// struct or a map. Struct fields are matched against the parameter keys
+// with their confloader tag, like `confloader:"paramObj.paramInt"`, or else
+// their json tag or their name, compared case-insensitively. A tag of "-"
+// skips the field. Nested structs are decoded from the parameters under the
+// key of their field, and maps like with encoding/json.
+// Fields can be strings, numbers, booleans, time.Duration, or slices of
+// those. Values are converted like with LoadWithSchema, and an error is
+// returned if a value cannot be converted to the type of its field. Fields
+// without parameter, or whose parameter is null, are left unchanged. It
+// works with both flattened Configs and Configs loaded with WithoutFlatten.
+// The key segments are delimited by ".", or by the delimiter given by
+// WithDelimiter.
+func (c *Config) Unmarshal(v interface{}, opts ...Option) error {
+	return c.unmarshal(v, newOptions(opts).delim())
+}
+
+// unmarshal is Unmarshal with the delimiter d.
+func (c *Config) unmarshal(v interface{}, d string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("Unmarshal needs a non-nil pointer")
+	}
+	if rv.Elem().Kind() != reflect.Struct {
+		var tree interface{} = map[string]interface{}(*c)
+		if !c.isNested() {
+			tree = c.unflatten(d)
+		}
+		blob, err := json.Marshal(tree)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(blob, v)
+	}
+	flat := *c
+	if c.isNested() {
+		var err error
+		// values were expanded when the Config was loaded.
+		if flat, err = (&options{raw: true, delimiter: d}).flatten(map[string]interface{}(*c)); err != nil {
+			return err
+		}
+	}
+	return flat.decodeStruct(rv.Elem(), "", d)
+}
+
+// Marshal encodes the Config in format, ".json", ".yml" or ".yaml". The
+// nested objects are rebuilt from the keys, so "paramObj.paramInt" is
+// encoded as the member paramInt of the object paramObj, and the objects
+// whose keys are the indexes 0 to n-1, like the elements of arrays of
+// objects, are encoded as arrays. Loading the result gives back an equal
+// Config. The key segments are delimited by ".", or by the delimiter given
+// by WithDelimiter.
+func (c *Config) Marshal(format string, opts ...Option) ([]byte, error) {
+	var tree interface{} = map[string]interface{}(*c)
+	if !c.isNested() {
+		tree = rebuildArrays(c.unflatten(newOptions(opts).delim()))
+	}
+	switch format {
+	case ".json":
+		return json.MarshalIndent(tree, "", "    ")
+	case ".yml", ".yaml":
+		return yaml.Marshal(tree)
+	}
+	return nil, &UnsupportedFormatError{Format: format}
+}
+
+// WriteFile writes the Config to filename, encoded with Marshal in the
+// format given by the file name extension, and the delimiter given by
+// WithDelimiter.
+func (c *Config) WriteFile(filename string, opts ...Option) error {
+	data, err := c.Marshal(path.Ext(filename), opts...)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// rebuildArrays replaces, recursively, the objects of tree whose keys are
+// the indexes 0 to n-1 by arrays.
+func rebuildArrays(tree interface{}) interface{} {
+	m, ok := tree.(map[string]interface{})
+	if !ok {
+		return tree
+	}
+	for k, v := range m {
+		m[k] = rebuildArrays(v)
+	}
+	arr := make([]interface{}, len(m))
+	for k, v := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(arr) || strconv.Itoa(i) != k {
+			return m
+		}
+		arr[i] = v
+	}
+	if len(arr) == 0 {
+		return m
+	}
+	return arr
+}
+
+// SaveCache saves the parameters of the Config to the cache file
+// filename, in a compact binary format that LoadCache reads faster than
+// the configuration file itself. Sensitive parameters stay sensitive.
+func (c *Config) SaveCache(filename string) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(map[string]interface{}(*c)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// LoadCache loads a Config saved with SaveCache. If source files are
+// given, typically the configuration files the Config was loaded from, an
+// error is returned when one of them was modified after the cache was
+// saved, so that the caller can load them again.
+func LoadCache(filename string, sources ...string) (Config, error) {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, source := range sources {
+		sfi, err := os.Stat(source)
+		if err != nil {
+			return Config{}, err
+		}
+		if sfi.ModTime().After(fi.ModTime()) {
+			return Config{}, errors.New("Cache is older than " + source)
+		}
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+	var cache map[string]interface{}
+	if err := gob.NewDecoder(f).Decode(&cache); err != nil {
+		return Config{}, err
+	}
+	return Config(cache), nil
+}
+
+/*
+ * internal code
+ */
+
+func init() {
+	// the nested values of a Config loaded with WithoutFlatten are
+	// encoded as interfaces by SaveCache.
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+	// large integers are stored as json.Number.
+	gob.Register(json.Number(""))
+	gob.Register(Sensitive{})
+}
+
+// redacted replaces the values of sensitive parameters in String and ToEnv.
+const redacted = "******"
+
+// schemaEntry is the declaration of a parameter in a LoadWithSchema schema.
+type schemaEntry struct {
+	Type      string `json:"type"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+// UnmarshalJSON decodes a schemaEntry from either a type name or an object.
+func (e *schemaEntry) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &e.Type); err == nil {
+		return nil
+	}
+	type entry schemaEntry
+	return json.Unmarshal(data, (*entry)(e))
+}
+
+// unwrap returns the value wrapped by v if it is Sensitive, or v.
+func unwrap(v interface{}) interface{} {
+	if s, ok := v.(Sensitive); ok {
+		return s.Value
+	}
+	return v
+}
+
+// displayString returns the string value of parameter k, or a mask if
+// it is sensitive.
+func (c *Config) displayString(k string) string {
+	if _, ok := (*c)[k].(Sensitive); ok {
+		return redacted
+	}
+	return c.GetString(k)
+}
+
+// merge copies the parameters of other into c, replacing existing ones.
+// Null parameters of other don't replace existing ones. Arrays are
+// replaced as a whole, along with their index keys, or appended to arrays
+// of the same type if appendArrays is true. Key segments are delimited
+// by d.
+func (c *Config) merge(other Config, appendArrays bool, d string) {
+	for k, v := range other {
+		if other.isArrayIndex(k) {
+			continue
+		}
+		if _, ok := (*c)[k]; ok && v == nil {
+			continue
+		}
+		if appendArrays {
+			switch a := unwrap(v).(type) {
+			case []string:
+				if b, ok := c.Get(k).([]string); ok {
+					v = append(append([]string(nil), b...), a...)
+				}
+			case []float64:
+				if b, ok := c.Get(k).([]float64); ok {
+					v = append(append([]float64(nil), b...), a...)
+				}
+			case []bool:
+				if b, ok := c.Get(k).([]bool); ok {
+					v = append(append([]bool(nil), b...), a...)
+				}
+			}
+		}
+		c.set(k, v, d)
+	}
+}
+
+// set sets parameter k to v. If k holds an array, its index keys are
+// removed, and if v is an array, the index keys of its elements are set.
+// If k or v is sensitive, so are the new values.
+func (c *Config) set(k string, v interface{}, d string) {
+	for i := range c.GetScalarOrArray(k) {
+		if c.isArrayIndex(k + d + strconv.Itoa(i)) {
+			delete(*c, k+d+strconv.Itoa(i))
+		}
+	}
+	_, sensitive := (*c)[k].(Sensitive)
+	if s, ok := v.(Sensitive); ok {
+		v, sensitive = s.Value, true
+	}
+	if sensitive {
+		(*c)[k] = Sensitive{Value: v}
+	} else {
+		(*c)[k] = v
+	}
+	switch v.(type) {
+	case []string, []float64, []bool:
+		for i, e := range c.GetScalarOrArray(k) {
+			if sensitive {
+				e = Sensitive{Value: e}
+			}
+			(*c)[k+d+strconv.Itoa(i)] = e
+		}
+	}
+}
+
+// envName returns the name of the environment variable of parameter k:
+// k prefixed with prefix, upper-cased, with the characters other than
+// letters and digits, like delimiters and dashes, replaced by underscores.
+func envName(prefix, k string) string {
+	return prefix + strings.ToUpper(strings.Map(func(r rune) rune {
+		if isDelimiterRune(r) {
+			return '_'
+		}
+		return r
+	}, k))
+}
+
+// BindEnv overrides the parameters of the Config with the environment
+// variables named after their key, if they are set. The name of the
+// variable of a parameter is its key upper-cased, with the characters
+// other than letters and digits, like delimiters and dashes, replaced by
+// underscores, and prefixed with prefix: with prefix "APP_", server.port is
+// overridden by APP_SERVER_PORT. Numbers and booleans stay typed when the
+// variable can be parsed, and are replaced by the string otherwise. Arrays
+// are replaced by the string, which can be read as an array with
+// GetCSVArray. Only existing parameters are overridden.
+func (c *Config) BindEnv(prefix string) {
+	// the index keys of arrays, removed with the array they belong to.
+	indexes := make(map[string][]string)
+	for k := range *c {
+		if base, _, ok := c.arrayIndex(k); ok {
+			indexes[base] = append(indexes[base], k)
+		}
+	}
+	var bound []string
+	values := make(map[string]interface{})
+	for k := range *c {
+		if c.isArrayIndex(k) {
+			continue
+		}
+		v, ok := os.LookupEnv(envName(prefix, k))
+		if !ok {
+			continue
+		}
+		bound = append(bound, k)
+		values[k] = v
+		// keep numbers and booleans typed when the variable can be parsed.
+		switch c.Get(k).(type) {
+		case float64:
+			if f, err := coerce(v, "float"); err == nil {
+				values[k] = f
+			}
+		case bool:
+			if b, err := coerce(v, "bool"); err == nil {
+				values[k] = b
+			}
+		}
+	}
+	for _, k := range bound {
+		for _, index := range indexes[k] {
+			delete(*c, index)
+		}
+		if _, ok := (*c)[k].(Sensitive); ok {
+			(*c)[k] = Sensitive{Value: values[k]}
+		} else {
+			(*c)[k] = values[k]
+		}
+	}
+}
+
+// isNested reports whether the Config holds nested objects or arrays,
+// which is the case when it was loaded with WithoutFlatten.
+func (c *Config) isNested() bool {
+	for _, v := range *c {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return true
+		}
+	}
+	return false
+}
+
+// unflatten rebuilds the nested objects of a flattened Config, whose key
+// segments are delimited by d. Index keys of arrays are skipped since
+// arrays are stored under their own key, but arrays holding large
+// integers are rebuilt from them so that the integers stay exact.
+func (c *Config) unflatten(d string) map[string]interface{} {
+	tree := make(map[string]interface{})
+	for k, v := range *c {
+		if c.isArrayIndex(k) {
+			continue
+		}
+		v = unwrap(v)
+		if arr, ok := v.([]float64); ok {
+			elems := make([]interface{}, len(arr))
+			big := false
+			for i, f := range arr {
+				elems[i] = c.arrayElement(k, i, f)
+				if _, ok := elems[i].(json.Number); ok {
+					big = true
+				}
+			}
+			if big {
+				v = elems
+			}
+		}
+		node := tree
+		parts := strings.Split(k, d)
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = v
+	}
+	return tree
+}
+
+// durationType is the type of time.Duration fields, decoded from duration
+// strings rather than from numbers.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// decodeStruct sets the fields of the struct rv from the parameters under
+// prefix, whose key segments are delimited by d. See Unmarshal.
+func (c *Config) decodeStruct(rv reflect.Value, prefix, d string) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("confloader"); ok {
+			name = tag
+		} else if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" {
+			name = tag
+		}
+		if name == "-" {
+			continue
+		}
+		key, ok := c.findKey(prefix+name, d)
+		if !ok {
+			continue
+		}
+		if err := c.decodeValue(rv.Field(i), key, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeValue sets fv from parameter key, or from the parameters under key
+// if fv is a struct or a map.
+func (c *Config) decodeValue(fv reflect.Value, key, d string) error {
+	// null parameters leave their field unchanged, like missing ones.
+	v, ok := (*c)[key]
+	v = unwrap(v)
+	if ok && v == nil {
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.Struct:
+		return c.decodeStruct(fv, key+d, d)
+	case reflect.Ptr:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return c.decodeValue(fv.Elem(), key, d)
+	case reflect.Map:
+		sub := c.sub(key, d)
+		return sub.unmarshal(fv.Addr().Interface(), d)
+	case reflect.Interface:
+		if ok {
+			fv.Set(reflect.ValueOf(v))
+		}
+		return nil
+	case reflect.Slice:
+		elems := c.GetScalarOrArray(key)
+		arr := reflect.MakeSlice(fv.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			if err := decodeScalar(arr.Index(i), elem); err != nil {
+				return errors.New("Parameter " + key + d + strconv.Itoa(i) + " cannot be decoded: " + err.Error())
+			}
+		}
+		fv.Set(arr)
+		return nil
+	}
+	if err := decodeScalar(fv, v); err != nil {
+		return errors.New("Parameter " + key + " cannot be decoded: " + err.Error())
+	}
+	return nil
+}
+
+// decodeScalar sets fv from the scalar value v, converted with coerce.
+func decodeScalar(fv reflect.Value, v interface{}) error {
+	typ := ""
+	switch fv.Kind() {
+	case reflect.String:
+		typ = "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		typ = "int"
+	case reflect.Float32, reflect.Float64:
+		typ = "float"
+	case reflect.Bool:
+		typ = "bool"
+	default:
+		return errors.New("Type " + fv.Type().String() + " is not supported")
+	}
+	if fv.Type() == durationType {
+		typ = "duration"
+	}
+	if n, ok := v.(json.Number); ok && typ == "int" {
+		if fv.Kind() >= reflect.Uint && fv.Kind() <= reflect.Uint64 {
+			u, err := strconv.ParseUint(n.String(), 10, 64)
+			if err != nil || fv.OverflowUint(u) {
+				return errors.New("Value " + n.String() + " overflows " + fv.Type().String())
+			}
+			fv.SetUint(u)
+		} else {
+			i, err := n.Int64()
+			if err != nil || fv.OverflowInt(i) {
+				return errors.New("Value " + n.String() + " overflows " + fv.Type().String())
+			}
+			fv.SetInt(i)
+		}
+		return nil
+	}
+	res, err := coerce(v, typ)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case "string":
+		fv.SetString(res.(string))
+	case "duration":
+		d, _ := parseDuration(res.(string))
+		fv.SetInt(int64(d))
+	case "bool":
+		fv.SetBool(res.(bool))
+	case "float":
+		fv.SetFloat(res.(float64))
+	case "int":
+		f := res.(float64)
+		if fv.Kind() >= reflect.Uint && fv.Kind() <= reflect.Uint64 {
+			if f < 0 || f >= math.MaxUint64 || fv.OverflowUint(uint64(f)) {
+				return errors.New("Value " + strconv.FormatFloat(f, 'f', -1, 64) + " overflows " + fv.Type().String())
+			}
+			fv.SetUint(uint64(f))
+		} else {
+			if f < math.MinInt64 || f >= math.MaxInt64 || fv.OverflowInt(int64(f)) {
+				return errors.New("Value " + strconv.FormatFloat(f, 'f', -1, 64) + " overflows " + fv.Type().String())
+			}
+			fv.SetInt(int64(f))
+		}
+	}
+	return nil
+}
+
+// findKey returns the key of parameter p, or of the object holding the
+// parameters under p. If there is no exact match, keys are compared
+// case-insensitively. Key segments are delimited by d.
+func (c *Config) findKey(p, d string) (string, bool) {
+	var found string
+	for k := range *c {
+		if len(k) > len(p) && strings.HasPrefix(k[len(p):], d) {
+			k = k[:len(p)]
+		}
+		if k == p {
+			return p, true
+		}
+		if strings.EqualFold(k, p) && (found == "" || k < found) {
+			found = k
+		}
+	}
+	return found, found != ""
+}
+
+// deepCopy returns a copy of the Config that doesn't share any array or
+// nested object with it.
+func (c Config) deepCopy() Config {
+	fields := make(Config, len(c))
+	for k, v := range c {
+		fields[k] = copyValue(v)
+	}
+	return fields
+}
+
+// copyValue returns a deep copy of the parameter value v. Arrays are
+// copied, as well as the nested objects and arrays of Configs loaded with
+// WithoutFlatten.
+func copyValue(v interface{}) interface{} {
+	switch a := v.(type) {
+	case []string:
+		return append([]string(nil), a...)
+	case []float64:
+		return append([]float64(nil), a...)
+	case []bool:
+		return append([]bool(nil), a...)
+	case []interface{}:
+		arr := make([]interface{}, len(a))
+		for i, e := range a {
+			arr[i] = copyValue(e)
+		}
+		return arr
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(a))
+		for k, e := range a {
+			m[k] = copyValue(e)
+		}
+		return m
+	case Sensitive:
+		return Sensitive{Value: copyValue(a.Value)}
+	}
+	return v
+}
+
+// isArrayIndex reports whether key k is the index key of an element of
+// an array parameter, like "param.0" for the array "param".
+func (c *Config) isArrayIndex(k string) bool {
+	_, _, ok := c.arrayIndex(k)
+	return ok
+}
+
+// arrayIndex splits the index key k of an element of an array parameter,
+// like "param.0", into the key of the array and the index of the element.
+// Delimiters are made of other characters than letters and digits, so the
+// key of the array is found whatever the delimiter, by trimming them one
+// by one. ok is false if k is not an index key.
+func (c *Config) arrayIndex(k string) (base string, i int, ok bool) {
+	j := len(k)
+	for j > 0 && k[j-1] >= '0' && k[j-1] <= '9' {
+		j--
+	}
+	i, err := strconv.Atoi(k[j:])
+	if err != nil || strconv.Itoa(i) != k[j:] {
+		return "", 0, false
+	}
+	v := unwrap((*c)[k])
+	for base = k[:j]; base != ""; {
+		r, size := utf8.DecodeLastRuneInString(base)
+		if !isDelimiterRune(r) {
+			break
+		}
+		base = base[:len(base)-size]
+		if isElement(unwrap((*c)[base]), i, v) {
+			return base, i, true
+		}
+	}
+	return "", 0, false
+}
+
+// isElement reports whether v is element i of the array value arr.
+func isElement(arr interface{}, i int, v interface{}) bool {
+	switch arr := arr.(type) {
+	case []string:
+		s, ok := toString(v)
+		return i < len(arr) && ok && s == arr[i]
+	case []float64:
+		// elements set by hand can be of any number type.
+		f, ok := toFloat(canonicalValue(v))
+		return i < len(arr) && ok && f == arr[i]
+	case []bool:
+		b, ok := v.(bool)
+		return i < len(arr) && ok && b == arr[i]
+	}
+	return false
+}
+
+// flattenArray returns the value stored under the key of array arr: a
+// []string, []float64 or []bool if all the elements are strings, numbers
+// or booleans, or a []string of the elements converted to strings if they
+// are scalars of different types. It returns nil if some elements are
+// objects, arrays or null, which are only stored under their index keys.
+func (o *options) flattenArray(arr []interface{}) (interface{}, error) {
+	var strs []string
+	var nums []float64
+	var bools []bool
+	for _, k := range arr {
+		switch k := k.(type) {
+		case string:
+			v, err := o.expandValue(k)
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, v)
+		case int:
+			nums = append(nums, float64(k))
+			strs = append(strs, strconv.Itoa(k))
+		case float64:
+			nums = append(nums, k)
+			strs = append(strs, strconv.FormatFloat(k, 'f', -1, 64))
+		case json.Number:
+			// the index key of the element keeps its exact value.
+			f, _ := k.Float64()
+			nums = append(nums, f)
+			strs = append(strs, k.String())
+		case bool:
+			bools = append(bools, k)
+			strs = append(strs, strconv.FormatBool(k))
+		default:
+			return nil, nil
+		}
+	}
+	if len(nums) == len(arr) {
+		return nums, nil
+	} else if len(bools) == len(arr) {
+		return bools, nil
+	}
+	return strs, nil
+}
+
+// delim returns the key delimiter set by WithDelimiter, or ".".
+func (o *options) delim() string {
+	if o.delimiter == "" {
+		return "."
+	}
+	return o.delimiter
+}
+
+// newOptions returns the options set by opts.
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// read reads the configuration file filename, or stdin if filename is "-",
+// and returns its content and its format as a file name extension.
+func (o *options) read(filename string) ([]byte, string, error) {
+	o.filename = filename
+	if filename == "-" {
+		blob, err := o.readAll(stdin)
+		return blob, sniffFormat(blob), err
+	}
+	found, err := findFile(filename)
+	if err != nil {
+		return []byte{}, "", err
+	}
+	o.baseDir = filepath.Dir(found)
+	if fi, err := os.Stat(found); err == nil {
+		if err := o.checkSize(fi.Size()); err != nil {
+			return []byte{}, "", err
+		}
+	}
+	blob, err := ioutil.ReadFile(found)
+	return blob, path.Ext(filename), err
+}
+
+// readAll reads r until EOF, or until more than the maximum size set by
+// WithMaxFileSize is read.
+func (o *options) readAll(r io.Reader) ([]byte, error) {
+	if o.maxSize > 0 {
+		r = io.LimitReader(r, o.maxSize+1)
+	}
+	return ioutil.ReadAll(r)
+}
+
+// checkSize returns an error if size exceeds the maximum size set by
+// WithMaxFileSize.
+func (o *options) checkSize(size int64) error {
+	if o.maxSize > 0 && size > o.maxSize {
+		return errors.New("Configuration file exceeds maximum size of " + strconv.FormatInt(o.maxSize, 10) + " bytes")
+	}
+	return nil
+}
+
+// load parses the configuration data according to format and builds a
+// Config from it.
+func (o *options) load(data []byte, format string) (Config, error) {
+	if err := o.checkSize(int64(len(data))); err != nil {
+		return Config{}, err
+	}
+	raw, err := o.parse(data, format)
+	if err != nil {
+		return Config{}, err
+	}
+	return o.build(raw)
+}
+
+// parse unmarshals the configuration data according to format and
+// validates the result if a validation function is set.
+func (o *options) parse(data []byte, format string) (interface{}, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		if o.allowEmpty {
+			return nil, nil
+		}
+		return nil, ErrEmptyConfig
+	}
+	isYAML := format == ".yml" || format == ".yaml"
+	if o.preserveHash && isYAML {
+		data = quoteHashValues(data)
+	}
+	var raw interface{}
+	var err error
+	// objects of duplicate keys are merged, which needs the duplicates
+	// that decoding into maps discards.
+	if format == ".json" {
+		raw, err = decodeJSON(data)
+	} else {
+		err = unmarshal(format, data, &raw)
+	}
+	if err != nil {
+		if !isSupportedFormat(format) {
+			return nil, err
+		}
+		return nil, &ParseError{Filename: o.filename, Err: err}
+	}
+	if o.strict {
+		if dups := duplicateKeys(data, format, o.delim()); len(dups) > 0 {
+			return nil, errors.New("Configuration has duplicate keys: " + strings.Join(dups, ", "))
+		}
+	}
+	if _, ok := raw.(map[interface{}]interface{}); ok && isYAML {
+		var ms yaml.MapSlice
+		if err := yaml.Unmarshal(data, &ms); err != nil {
+			return nil, &ParseError{Filename: o.filename, Err: err}
+		}
+		raw = mergeDuplicates(ms)
+		if o.keyOrder {
+			o.order = []string{}
+		}
+	}
+	if o.validate != nil {
+		tree, err := o.normalize(raw)
+		if err != nil {
+			return nil, err
+		}
+		if err := o.validate(tree); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+// build turns a parsed configuration into a Config, flattening it unless
+// WithoutFlatten is set.
+func (o *options) build(raw interface{}) (Config, error) {
+	if d := o.delim(); strings.IndexFunc(d, unicode.IsLetter) >= 0 || strings.IndexFunc(d, unicode.IsDigit) >= 0 {
+		return Config{}, errors.New("Delimiter " + strconv.Quote(d) + " cannot contain letters or digits")
+	}
+	root := foldKey(o.rootKey, o.styleless, o.caseless)
+	if o.noFlatten {
+		tree, err := o.normalize(raw)
+		if err != nil {
+			return Config{}, err
+		}
+		m, ok := tree.(map[string]interface{})
+		if !ok {
+			m = make(map[string]interface{})
+		}
+		if o.rootKey != "" && len(m) > 0 {
+			for _, part := range strings.Split(root, o.delim()) {
+				if len(m) != 1 {
+					return Config{}, errors.New("Configuration has parameters that are not under root key " + o.rootKey)
+				}
+				if m, ok = m[part].(map[string]interface{}); !ok {
+					return Config{}, errors.New("Configuration has parameters that are not under root key " + o.rootKey)
+				}
+			}
+		}
+		return Config(m), nil
+	}
+	cnf, err := o.flatten(raw)
+	if err != nil {
+		return Config{}, err
+	}
+	if o.rootKey != "" {
+		d := o.delim()
+		for k := range cnf {
+			if !strings.HasPrefix(k, root+d) {
+				return Config{}, errors.New("Parameter " + k + " is not under root key " + o.rootKey)
+			}
+		}
+		cnf = cnf.sub(root, d)
+		if o.order != nil {
+			order := []string{}
+			for _, k := range o.order {
+				if strings.HasPrefix(k, root+d) {
+					order = append(order, k[len(root+d):])
+				}
+			}
+			o.order = order
+		}
+	}
+	return cnf, nil
+}
+
+// flatten takes an interface and extract all of its values and put them in a map.
+func (o *options) flatten(obj interface{}, prefix ...string) (Config, error) {
+	fields := make(Config)
+	d := o.delim()
+
+	var pre string
+	if len(prefix) > 0 {
+		pre = pre + prefix[0]
+	}
+
+	switch obj.(type) {
+	case map[interface{}]interface{}:
+		seen := make(map[string]string)
+		for key, value := range obj.(map[interface{}]interface{}) {
+			key, err := o.normalizeKey(keyString(key), seen)
+			if err != nil {
+				return Config{}, err
+			}
+			res, err := o.flatten(value, pre+key+d)
+			if err != nil {
+				return Config{}, err
+			}
+			for k, v := range res {
+				fields[strings.TrimRight(k, d)] = v
+			}
+		}
+	case yaml.MapSlice:
+		seen := make(map[string]string)
+		for _, item := range mergeDuplicates(obj.(yaml.MapSlice)) {
+			key, err := o.normalizeKey(keyString(item.Key), seen)
+			if err != nil {
+				return Config{}, err
+			}
+			res, err := o.flatten(item.Value, pre+key+d)
+			if err != nil {
+				return Config{}, err
+			}
+			for k, v := range res {
+				fields[strings.TrimRight(k, d)] = v
+			}
+		}
+	case map[string]interface{}:
+		seen := make(map[string]string)
+		for key, value := range obj.(map[string]interface{}) {
+			key, err := o.normalizeKey(key, seen)
+			if err != nil {
+				return Config{}, err
+			}
+			res, err := o.flatten(value, pre+key+d)
+			if err != nil {
+				return Config{}, err
+			}
+			for k, v := range res {
+				fields[strings.TrimRight(k, d)] = v
+			}
+		}
+	case []map[string]interface{}:
+		// HCL blocks of the same type are merged, so that labeled blocks
+		// are under their labels, unless they set the same parameters,
+		// like repeated blocks, which are indexed like array elements.
+		blocks := obj.([]map[string]interface{})
+		for _, value := range blocks {
+			res, err := o.flatten(value, pre)
+			if err != nil {
+				return Config{}, err
+			}
+			for k, v := range res {
+				if _, ok := fields[k]; ok {
+					return o.flatten(blockArray(blocks), pre)
+				}
+				fields[k] = v
+			}
+		}
+	case []interface{}:
+		if len(obj.([]interface{})) == 0 {
+			break
+		}
+		o.recordKey(pre)
+		arr, err := o.flattenArray(obj.([]interface{}))
+		if err != nil {
+			return Config{}, err
+		}
+		if arr != nil {
+			fields[pre] = arr
+		}
+		for index, value := range obj.([]interface{}) {
+			res, err := o.flatten(value, pre+strconv.Itoa(index)+d)
+			if err != nil {
+				return Config{}, err
+			}
+			for k, v := range res {
+				fields[strings.TrimRight(k, d)] = v
+			}
+		}
+	case int:
+		o.recordKey(pre)
+		fields[strings.TrimRight(pre, d)] = intValue(int64(obj.(int)))
+	case float64, json.Number:
+		o.recordKey(pre)
+		fields[strings.TrimRight(pre, d)] = obj
+	case string:
+		o.recordKey(pre)
+		v, err := o.expandValue(obj.(string))
+		if err != nil {
+			return Config{}, err
+		}
+		if o.envJSONArrays && strings.HasPrefix(obj.(string), "$") {
+			var val interface{}
+			if err := json.Unmarshal([]byte(v), &val); err == nil {
+				switch val.(type) {
+				case []interface{}, map[string]interface{}:
+					return o.flatten(val, pre)
+				}
+			}
+		}
+		if o.csvArrays && strings.HasPrefix(obj.(string), "$") && strings.Contains(v, ",") {
+			return o.flatten(splitCSV(v), pre)
+		}
+		fields[strings.TrimRight(pre, d)] = v
+	case bool:
+		o.recordKey(pre)
+		fields[strings.TrimRight(pre, d)] = obj.(bool)
+	case nil:
+		// null values are kept so that Has reports them.
+		if pre != "" {
+			fields[strings.TrimRight(pre, d)] = nil
+		}
+	}
+
+	return fields, nil
+}
+
+// ErrUnsupportedFormat is reported, through an UnsupportedFormatError, when
+// a configuration is in a format confloader cannot read. Test for it with
+// errors.Is.
+var ErrUnsupportedFormat = errors.New("Unrecognized file format")
+
+// UnsupportedFormatError is returned when the file name extension, or the
+// format given to LoadFromBytes and LoadFromReader, is not supported.
+type UnsupportedFormatError struct {
+	Format string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return ErrUnsupportedFormat.Error() + " " + e.Format
+}
+
+// Is reports whether target is ErrUnsupportedFormat.
+func (e *UnsupportedFormatError) Is(target error) bool {
+	return target == ErrUnsupportedFormat
+}
+
+// ErrMissingParameter is reported, through a ParameterError, when a
+// parameter doesn't exist.
+var ErrMissingParameter = errors.New("Parameter is missing")
+
+// ErrInvalidValue is reported, through a ParameterError, when the value of
+// a parameter cannot be converted to the requested type.
+var ErrInvalidValue = errors.New("Parameter value cannot be converted")
+
+// ParameterError is returned by the E variants of the getters, like
+// GetStringE, when parameter Key is missing or when its value cannot be
+// converted to Type. Err is either ErrMissingParameter or ErrInvalidValue.
+// Cause is the parsing error of the value, if any, like the error of
+// time.ParseDuration for GetDurationE.
+type ParameterError struct {
+	Key   string
+	Type  string
+	Err   error
+	Cause error
+}
+
+func (e *ParameterError) Error() string {
+	if e.Err == ErrMissingParameter {
+		return "Parameter " + e.Key + " is missing"
+	}
+	msg := "Parameter " + e.Key + " cannot be converted to " + e.Type
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+// Unwrap returns ErrMissingParameter or ErrInvalidValue.
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
+
+// ErrEmptyConfig is returned when a configuration file is empty or only
+// holds whitespace, unless WithAllowEmpty is set.
+var ErrEmptyConfig = errors.New("Configuration file is empty")
+
+// ParseError is returned when a configuration cannot be decoded. Err is the
+// error of the underlying decoder. Filename is empty for configurations
+// that don't come from a file.
+type ParseError struct {
+	Filename string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	if e.Filename == "" {
+		return e.Err.Error()
+	}
+	return e.Filename + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the decoder.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// isSupportedFormat reports whether the file name extension format is
+// supported by unmarshal.
+func isSupportedFormat(format string) bool {
+	switch format {
+	case ".json", ".yml", ".yaml", ".hcl", ".env", ".ini", ".properties":
+		return true
+	}
+	return false
+}
+
+// unmarshal calls either json.Unmarshal, yaml.Unmarshal, hcl.Unmarshal
+// or unmarshalFlat with the parameters of .env, .ini or .properties files,
+// depending on configuration file name extension.
+func unmarshal(format string, data []byte, v interface{}) error {
+	if format == ".json" {
+		return json.Unmarshal(data, v)
+	} else if format == ".yml" || format == ".yaml" {
+		return yaml.Unmarshal(data, v)
+	} else if format == ".hcl" {
+		return hcl.Unmarshal(data, v)
+	} else if format == ".env" {
+		vars, err := parseDotenv(data)
+		if err != nil {
+			return err
+		}
+		return unmarshalFlat(vars, v)
+	} else if format == ".ini" || format == ".properties" {
+		vars, err := parseINI(data, format)
+		if err != nil {
+			return err
+		}
+		return unmarshalFlat(vars, v)
+	}
+	return &UnsupportedFormatError{Format: format}
+}
+
+// unmarshalFlat stores in v, which should be a pointer to an interface{}
+// or to a map[string]interface{}, the parameters of formats whose keys are
+// already flattened, like .env files. Dotted keys are kept as is, so that
+// they are the same as the keys of nested objects once flattened.
+func unmarshalFlat(vars map[string]string, v interface{}) error {
+	m := make(map[string]interface{}, len(vars))
+	for k, val := range vars {
+		m[k] = val
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || !reflect.TypeOf(m).AssignableTo(rv.Elem().Type()) {
+		return errors.New("Cannot unmarshal into " + rv.Type().String())
+	}
+	rv.Elem().Set(reflect.ValueOf(m))
+	return nil
+}
+
+// validationMessages returns the location and message of the leaf causes
+// of a JSON Schema validation error.
+func validationMessages(ve *jsonschema.ValidationError) []string {
+	if len(ve.Causes) == 0 {
+		loc := ve.InstanceLocation
+		if loc == "" {
+			loc = "/"
+		}
+		return []string{loc + ": " + ve.Message}
+	}
+	var msgs []string
+	for _, cause := range ve.Causes {
+		msgs = append(msgs, validationMessages(cause)...)
+	}
+	return msgs
+}
+
+// coerce converts the scalar value v to the type typ of a schema.
+// Numbers are stored as float64 and durations as strings, like in a
+// loaded Config.
+func coerce(v interface{}, typ string) (interface{}, error) {
+	c := Config{"v": v}
+	_, isString := v.(string)
+	switch v.(type) {
+	case string, float64, bool:
+	case json.Number:
+		// large integers are kept as is, like in Load.
+		if typ == "int" {
+			return v, nil
+		}
+	default:
+		return nil, errors.New("Value is not a scalar")
+	}
+	switch typ {
+	case "string":
+		return c.GetString("v"), nil
+	case "int", "float":
+		f := c.GetFloat("v")
+		if isString {
+			var err error
+			if f, err = strconv.ParseFloat(v.(string), 64); err != nil {
+				return nil, errors.New("Value " + v.(string) + " is not a number")
+			}
+		}
+		if typ == "int" && f != math.Trunc(f) {
+			return nil, errors.New("Value " + strconv.FormatFloat(f, 'f', -1, 64) + " is not an integer")
+		}
+		return f, nil
+	case "bool":
+		if isString {
+			b, err := strconv.ParseBool(v.(string))
+			if err != nil {
+				return nil, errors.New("Value " + v.(string) + " is not a boolean")
+			}
+			return b, nil
+		}
+		return c.GetBool("v"), nil
+	case "duration":
+		if _, err := parseDuration(c.GetString("v")); err != nil {
+			return nil, err
+		}
+		return c.GetString("v"), nil
+	}
+	return nil, errors.New("Unknown type " + typ)
+}
+
+// byteUnits are the multipliers of the units of sizes, in lowercase.
+var byteUnits = map[string]float64{
+	"": 1, "b": 1,
+	"kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12, "pb": 1e15,
+	"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40, "pib": 1 << 50,
+}
+
+// parseBytes parses size s, a number followed by an optional unit, and
+// returns its value in bytes. See GetBytes.
+func parseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	f, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, errors.New("Size " + s + " is not a number")
+	}
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, errors.New("Size " + s + " has an unknown unit")
+	}
+	f *= unit
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, errors.New("Size " + s + " overflows int64")
+	}
+	return int64(f), nil
+}
+
+// parseDuration calls time.ParseDuration after removing the spaces and
+// commas that can separate the units of a compound duration.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.Map(func(r rune) rune {
+		if r == ',' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return time.ParseDuration(s)
+}
+
+// intSize is the size in bits of an int on the current platform.
+const intSize = 32 << (^uint(0) >> 63)
+
+// floatToInt converts f to an integer of the given size in bits,
+// or returns an error if f is out of range.
+func floatToInt(f float64, size uint) (int64, error) {
+	limit := math.Ldexp(1, int(size)-1)
+	if math.IsNaN(f) || f < -limit || f >= limit {
+		return 0, errors.New("Value " + strconv.FormatFloat(f, 'f', -1, 64) + " overflows int" + strconv.Itoa(int(size)))
+	}
+	return int64(f), nil
+}
+
+// hashValueRegexp matches a YAML line holding an unquoted scalar value
+// containing a #. The first group is the indentation, the sequence dash and
+// the key, plain or quoted, and the second group is the value.
+var hashValueRegexp = regexp.MustCompile(`^(\s*(?:-\s+)?(?:(?:[^\s#'"\[{-][^:#]*|"(?:[^"\\]|\\.)*"\s*|'(?:[^']|'')*'\s*):\s+)?)([^\s'"\[{|>&*!#-].*#.*?)\s*$`)
+
+// blockScalarRegexp matches a YAML line starting a literal or folded block
+// scalar, like "script: |". The group is the indentation of the key.
+var blockScalarRegexp = regexp.MustCompile(`^(\s*(?:-\s+)*)(?:(?:[^\s#'"\[{-][^:#]*|"(?:[^"\\]|\\.)*"\s*|'(?:[^']|'')*'\s*):\s+)?[|>][-+1-9]*(?:\s+#.*)?\s*$`)
+
+// quoteHashValues wraps in double quotes every unquoted YAML value
+// containing a # so that it is not interpreted as a comment. The content of
+// block scalars is left untouched since # doesn't start comments there.
+func quoteHashValues(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	block := -1
+	for i, line := range lines {
+		if block >= 0 {
+			trimmed := strings.TrimLeft(line, " ")
+			if trimmed == "" || len(line)-len(trimmed) > block {
+				continue
+			}
+			block = -1
+		}
+		if m := blockScalarRegexp.FindStringSubmatch(line); m != nil {
+			block = len(m[1])
+			continue
+		}
+		m := hashValueRegexp.FindStringSubmatch(line)
+		if m == nil || strings.TrimSpace(m[1]) == "" {
+			continue
+		}
+		lines[i] = m[1] + strconv.Quote(m[2])
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// normalize converts obj to a tree of map[string]interface{},
+// []interface{} and scalar values. Keys and values are transformed the
+// same way flatten does.
+func (o *options) normalize(obj interface{}) (interface{}, error) {
+	switch v := obj.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[keyString(key)] = value
+		}
+		return o.normalize(m)
+	case yaml.MapSlice:
+		m := make(map[string]interface{}, len(v))
+		for _, item := range mergeDuplicates(v) {
+			m[keyString(item.Key)] = item.Value
+		}
+		return o.normalize(m)
+	case []map[string]interface{}:
+		// HCL blocks are merged, or kept as an array if they are repeated.
+		// See flatten.
+		m := make(map[string]interface{})
+		for _, value := range v {
+			child, err := o.normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			if treesOverlap(m, child.(map[string]interface{})) {
+				return o.normalize(blockArray(v))
+			}
+			mergeTrees(m, child.(map[string]interface{}))
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		seen := make(map[string]string)
+		for key, value := range v {
+			key, err := o.normalizeKey(key, seen)
+			if err != nil {
+				return nil, err
+			}
+			if m[key], err = o.normalize(value); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, value := range v {
+			var err error
+			if arr[i], err = o.normalize(value); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case int:
+		return intValue(int64(v)), nil
+	case string:
+		s, err := o.expandValue(v)
+		if err != nil {
+			return nil, err
+		}
+		if o.envJSONArrays && strings.HasPrefix(v, "$") {
+			var val interface{}
+			if err := json.Unmarshal([]byte(s), &val); err == nil {
+				switch val.(type) {
+				case []interface{}, map[string]interface{}:
+					return o.normalize(val)
+				}
+			}
+		}
+		if o.csvArrays && strings.HasPrefix(v, "$") && strings.Contains(s, ",") {
+			return splitCSV(s), nil
+		}
+		return s, nil
+	}
+	return obj, nil
+}
+
+// splitCSV splits the comma separated values of s, trimming the spaces
+// around them. Elements are parsed as numbers if they all are numbers, as
+// booleans if they all are booleans, and kept as strings otherwise.
+func splitCSV(s string) []interface{} {
+	parts := strings.Split(s, ",")
+	strs := make([]interface{}, len(parts))
+	floats := make([]interface{}, len(parts))
+	bools := make([]interface{}, len(parts))
+	isFloat, isBool := true, true
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		strs[i] = part
+		var err error
+		if floats[i], err = strconv.ParseFloat(part, 64); err != nil {
+			isFloat = false
+		}
+		if bools[i], err = strconv.ParseBool(part); err != nil {
+			isBool = false
+		}
+	}
+	if isFloat {
+		return floats
+	} else if isBool {
+		return bools
+	}
+	return strs
+}
+
+// yamlChild returns the child of node named name: the value of key name
+// of a mapping, or the element of index name of a sequence. It returns nil
+// if there is no such child.
+func yamlChild(node *yamlv3.Node, name string) *yamlv3.Node {
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		if len(node.Content) > 0 {
+			return yamlChild(node.Content[0], name)
+		}
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				return node.Content[i+1]
+			}
+		}
+	case yamlv3.SequenceNode:
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
 	}
-	return s
+	return nil
 }
 
-// GetFloat gets float value of parameter p.
-// If parameter is a boolean, the number will be 1.0 if true, 0.0 if false.
-func (c *Config) GetFloat(p string) (f float64) {
-	switch v := c.Get(p).(type) {
-	case float64:
-		f = v
-	case bool:
-		if v {
-			f = 1.0
+// scalarEnd returns the length of the text of the scalar node at the start
+// of line, or -1 if the scalar doesn't end on this line.
+func scalarEnd(line string, node *yamlv3.Node) int {
+	switch node.Style {
+	case 0:
+		if strings.HasPrefix(line, node.Value) {
+			return len(node.Value)
 		}
-	case []float64:
-		if len(v) > 0 {
-			f = v[0]
+	case yamlv3.DoubleQuotedStyle:
+		for i := 1; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+			} else if line[i] == '"' {
+				return i + 1
+			}
 		}
-	case []bool:
-		if len(v) > 0 && v[0] {
-			f = 1.0
+	case yamlv3.SingleQuotedStyle:
+		for i := 1; i < len(line); i++ {
+			if line[i] == '\'' {
+				if i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
 		}
 	}
-	return f
+	return -1
 }
 
-// GetInt gets int value of parameter p.
-func (c *Config) GetInt(p string) int {
-	return int(c.GetFloat(p))
+// mergeDuplicates returns ms without duplicate keys. The objects of
+// duplicate keys are merged recursively, in place of the first key, while
+// other values replace the previous ones, in place of the last key.
+func mergeDuplicates(ms yaml.MapSlice) yaml.MapSlice {
+	merged := make(yaml.MapSlice, 0, len(ms))
+	for _, item := range ms {
+		item.Value = mergeDuplicatesIn(item.Value)
+		i := 0
+		for i < len(merged) && merged[i].Key != item.Key {
+			i++
+		}
+		if i == len(merged) {
+			merged = append(merged, item)
+			continue
+		}
+		prev, ok := merged[i].Value.(yaml.MapSlice)
+		next, ok2 := item.Value.(yaml.MapSlice)
+		if ok && ok2 {
+			merged[i].Value = mergeDuplicates(append(append(yaml.MapSlice{}, prev...), next...))
+		} else {
+			merged = append(append(merged[:i], merged[i+1:]...), item)
+		}
+	}
+	return merged
 }
 
-// GetDuration gets duration value of parameter p. p can have
-// suffixes like s, ms, h, etc. In fact the same as standard time.ParseDuration().
-func (c *Config) GetDuration(p string) (d time.Duration) {
-	d, _ = time.ParseDuration(c.GetString(p))
-	return d
+// duplicateKeys returns the paths of the keys that are defined more than
+// once in the same object of the JSON or YAML document data, like
+// "paramObj.param1" with delimiter d. Decoding into maps keeps only one of
+// them, so the document is scanned separately.
+func duplicateKeys(data []byte, format, d string) []string {
+	var dups []string
+	switch format {
+	case ".json":
+		jsonDuplicateKeys(json.NewDecoder(bytes.NewReader(data)), "", d, &dups)
+	case ".yml", ".yaml":
+		var ms yaml.MapSlice
+		if yaml.Unmarshal(data, &ms) == nil {
+			yamlDuplicateKeys(ms, "", d, &dups)
+		}
+	}
+	return dups
 }
 
-// GetBool gets number value of parameter p.
-// If parameter is a number, the boolean will be true if parameter is not 0,
-// false otherwise.
-func (c *Config) GetBool(p string) (b bool) {
-	switch v := c.Get(p).(type) {
-	case bool:
-		b = v
-	case float64:
-		if v != 0 {
-			b = true
-		}
-	case []bool:
-		if len(v) > 0 {
-			b = v[0]
+// jsonDuplicateKeys appends to dups the duplicate keys of the next value
+// of dec. See duplicateKeys.
+func jsonDuplicateKeys(dec *json.Decoder, prefix, d string, dups *[]string) {
+	tok, err := dec.Token()
+	if err != nil {
+		return
+	}
+	switch tok {
+	case json.Delim('{'):
+		seen := make(map[string]int)
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return
+			}
+			k := prefix + key.(string)
+			if seen[k]++; seen[k] == 2 {
+				*dups = append(*dups, k)
+			}
+			jsonDuplicateKeys(dec, k+d, d, dups)
 		}
-	case []float64:
-		if len(v) > 0 && v[0] != 0 {
-			b = true
+		dec.Token()
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			jsonDuplicateKeys(dec, prefix+strconv.Itoa(i)+d, d, dups)
 		}
+		dec.Token()
 	}
-	return b
 }
 
-// GetStringArray gets a string slice from parameter p.
-func (c *Config) GetStringArray(p string) (a []string) {
-	switch v := c.Get(p).(type) {
-	case []string:
-		a = v
-	case []float64:
-		arr := make([]string, len(v))
-		for i, k := range v {
-			arr[i] = strconv.FormatFloat(k, 'f', -1, 64)
+// yamlDuplicateKeys appends to dups the duplicate keys of v. See
+// duplicateKeys.
+func yamlDuplicateKeys(v interface{}, prefix, d string, dups *[]string) {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		seen := make(map[string]int)
+		for _, item := range v {
+			k := prefix + keyString(item.Key)
+			if seen[k]++; seen[k] == 2 {
+				*dups = append(*dups, k)
+			}
+			yamlDuplicateKeys(item.Value, k+d, d, dups)
 		}
-		a = arr
-	case []bool:
-		arr := make([]string, len(v))
-		for i, k := range v {
-			arr[i] = strconv.FormatBool(k)
+	case []interface{}:
+		for i, elem := range v {
+			yamlDuplicateKeys(elem, prefix+strconv.Itoa(i)+d, d, dups)
 		}
-		a = arr
-	case string:
-		a = []string{v}
-	case float64:
-		a = []string{strconv.FormatFloat(v, 'f', -1, 64)}
-	case bool:
-		a = []string{strconv.FormatBool(v)}
 	}
-	return a
 }
 
-// GetFloatArray gets a float64 slice from parameter p.
-func (c *Config) GetFloatArray(p string) (a []float64) {
-	switch v := c.Get(p).(type) {
-	case []float64:
-		a = v
-	case []bool:
-		arr := make([]float64, len(v))
-		for i, k := range v {
-			if k {
-				arr[i] = 1.0
-			} else {
-				arr[i] = 0.0
+// keyString converts the key of a YAML mapping to a string. YAML keys are
+// usually strings, but can also be numbers or booleans, like the ports of
+// "8080: backend", which are formatted with fmt.Sprint.
+func keyString(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// mergeDuplicatesIn calls mergeDuplicates on the objects of v, which can
+// be nested in arrays.
+func mergeDuplicatesIn(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		return mergeDuplicates(v)
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = mergeDuplicatesIn(elem)
+		}
+	}
+	return v
+}
+
+// decodeJSON decodes the JSON document data like json.Unmarshal into an
+// interface{}, except that the objects of duplicate keys are merged with
+// mergeTrees rather than replaced.
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("Invalid data after top-level JSON value")
+	}
+	return v, nil
+}
+
+// decodeJSONValue decodes the next JSON value of dec. See decodeJSON.
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := make(map[string]interface{})
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
 			}
+			v, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			mergeTrees(m, map[string]interface{}{key.(string): v})
 		}
-		a = arr
-	case float64:
-		a = []float64{v}
-	case bool:
-		if v {
-			a = []float64{1.0}
-		} else {
-			a = []float64{0.0}
+		_, err = dec.Token()
+		return m, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			if n, ok := v.(json.Number); ok {
+				if v, err = numberValue(n); err != nil {
+					return nil, err
+				}
+			}
+			arr = append(arr, v)
 		}
+		_, err = dec.Token()
+		return arr, err
 	}
-	return a
+	if n, ok := tok.(json.Number); ok {
+		return numberValue(n)
+	}
+	return tok, nil
 }
 
-// GetIntArray gets a int slice from parameter p.
-func (c *Config) GetIntArray(p string) []int {
-	arr := c.GetFloatArray(p)
-	a := make([]int, len(arr))
-	for i, k := range arr {
-		a[i] = int(k)
+// maxExactInt is the largest integer up to which all integers can be
+// represented exactly by a float64.
+const maxExactInt = 1 << 53
+
+// numberValue converts the JSON number n to a float64, unless it is an
+// integer that a float64 cannot represent exactly, which is kept as a
+// json.Number so that no precision is lost.
+func numberValue(n json.Number) (interface{}, error) {
+	if i, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		return intValue(i), nil
 	}
-	return a
+	f, err := n.Float64()
+	if err != nil {
+		return nil, errors.New("Number " + n.String() + " is out of range")
+	}
+	return f, nil
 }
 
-// GetDurationArray gets a duration slice from parameter p.
-func (c *Config) GetDurationArray(p string) []time.Duration {
-	arr := c.GetStringArray(p)
-	a := make([]time.Duration, len(arr))
-	for i, k := range arr {
-		a[i], _ = time.ParseDuration(k)
+// intValue converts i to a float64, like all numbers, or to a json.Number
+// if a float64 cannot represent it exactly.
+func intValue(i int64) interface{} {
+	if i > maxExactInt || i < -maxExactInt {
+		return json.Number(strconv.FormatInt(i, 10))
 	}
-	return a
+	return float64(i)
 }
 
-// GetBoolArray gets a bool slice from parameter p.
-func (c *Config) GetBoolArray(p string) (a []bool) {
-	switch v := c.Get(p).(type) {
-	case []bool:
-		a = v
-	case []float64:
-		arr := make([]bool, len(v))
-		for i, k := range v {
-			if k != 0 {
-				arr[i] = true
-			} else {
-				arr[i] = false
-			}
+// blockArray converts the HCL blocks to an array, to index them.
+func blockArray(blocks []map[string]interface{}) []interface{} {
+	arr := make([]interface{}, len(blocks))
+	for i, block := range blocks {
+		arr[i] = block
+	}
+	return arr
+}
+
+// treesOverlap reports whether merging src into dst with mergeTrees would
+// replace values of dst.
+func treesOverlap(dst, src map[string]interface{}) bool {
+	for k, v := range src {
+		d, ok := dst[k]
+		if !ok {
+			continue
 		}
-		a = arr
-	case bool:
-		a = []bool{v}
-	case float64:
-		if v != 0 {
-			a = []bool{true}
+		dstChild, ok := d.(map[string]interface{})
+		srcChild, ok2 := v.(map[string]interface{})
+		if !ok || !ok2 || treesOverlap(dstChild, srcChild) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeTrees merges src into dst. Objects present in both are merged
+// recursively, other values of src replace the ones of dst.
+func mergeTrees(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcChild, ok := v.(map[string]interface{})
+		dstChild, ok2 := dst[k].(map[string]interface{})
+		if ok && ok2 {
+			mergeTrees(dstChild, srcChild)
 		} else {
-			a = []bool{false}
+			dst[k] = v
 		}
 	}
-	return a
 }
 
-/*
- * internal code
- */
+// recordKey appends key to the declaration order if it is recorded.
+func (o *options) recordKey(key string) {
+	if o.order != nil {
+		o.order = append(o.order, strings.TrimRight(key, o.delim()))
+	}
+}
 
-// flatten takes an interface and extract all of its values and put them in a map.
-func flatten(obj interface{}, prefix ...string) Config {
-	fields := make(Config)
+// normalizeKey applies the key normalizer to key, if any. seen maps the
+// normalized keys of the current object to their original key and is used
+// to detect collisions.
+func (o *options) normalizeKey(key string, seen map[string]string) (string, error) {
+	if o.keyNormalizer == nil && !o.styleless && !o.caseless {
+		return key, nil
+	}
+	k := key
+	if o.keyNormalizer != nil {
+		k = o.keyNormalizer(k)
+	}
+	k = foldKey(k, o.styleless, o.caseless)
+	if orig, ok := seen[k]; ok && orig != key {
+		return "", errors.New("Keys " + orig + " and " + key + " are both normalized to " + k)
+	}
+	seen[k] = key
+	return k, nil
+}
 
-	var pre string
-	if len(prefix) > 0 {
-		pre = pre + prefix[0]
+// foldKey returns key in its canonical form: see canonicalKey if styleless
+// is set, or in lowercase if caseless is set.
+func foldKey(key string, styleless, caseless bool) string {
+	if styleless {
+		return canonicalKey(key)
+	} else if caseless {
+		return strings.ToLower(key)
 	}
+	return key
+}
 
-	switch obj.(type) {
-	case map[interface{}]interface{}:
-		for key, value := range obj.(map[interface{}]interface{}) {
-			res := flatten(value, pre+key.(string)+".")
-			for k, v := range res {
-				fields[strings.TrimRight(k, ".")] = v
-			}
-		}
-	case map[string]interface{}:
-		for key, value := range obj.(map[string]interface{}) {
-			res := flatten(value, pre+key+".")
-			for k, v := range res {
-				fields[strings.TrimRight(k, ".")] = v
-			}
+// isDelimiterRune reports whether r can be part of a key delimiter, which
+// is made of other characters than letters and digits.
+func isDelimiterRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// canonicalKey returns key in lowercase, without underscores and dashes.
+// Dots are kept so that key can be a path.
+func canonicalKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
 		}
-	case []interface{}:
-		if len(obj.([]interface{})) == 0 {
-			break
+		return unicode.ToLower(r)
+	}, key)
+}
+
+// expandValue replaces the string value v by the content of the file it
+// references if file value expansion is enabled, or by the value of the
+// environment variable it references.
+func (o *options) expandValue(v string) (string, error) {
+	if o.raw {
+		return v, nil
+	}
+	if o.fileValues && strings.HasPrefix(v, "@") {
+		if strings.HasPrefix(v, "@@") {
+			return v[1:], nil
 		}
-		switch obj.([]interface{})[0].(type) {
-		case string:
-			arr := make([]string, len(obj.([]interface{})))
-			for i, k := range obj.([]interface{}) {
-				arr[i] = getEnvValue(k.(string))
-			}
-			fields[pre] = arr
-		case int:
-			arr := make([]float64, len(obj.([]interface{})))
-			for i, k := range obj.([]interface{}) {
-				arr[i] = float64(k.(int))
-			}
-			fields[pre] = arr
-		case float64:
-			arr := make([]float64, len(obj.([]interface{})))
-			for i, k := range obj.([]interface{}) {
-				arr[i] = k.(float64)
-			}
-			fields[pre] = arr
-		case bool:
-			arr := make([]bool, len(obj.([]interface{})))
-			for i, k := range obj.([]interface{}) {
-				arr[i] = k.(bool)
-			}
-			fields[pre] = arr
+		filename := v[1:]
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(o.baseDir, filename)
 		}
-		for index, value := range obj.([]interface{}) {
-			res := flatten(value, pre+strconv.Itoa(index)+".")
-			for k, v := range res {
-				fields[strings.TrimRight(k, ".")] = v
-			}
+		blob, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return "", err
 		}
-	case int:
-		fields[strings.TrimRight(pre, ".")] = float64(obj.(int))
-	case float64:
-		fields[strings.TrimRight(pre, ".")] = obj.(float64)
-	case string:
-		v := getEnvValue(obj.(string))
-		fields[strings.TrimRight(pre, ".")] = v
-	case bool:
-		fields[strings.TrimRight(pre, ".")] = obj.(bool)
+		return string(blob), nil
 	}
-
-	return fields
+	return o.expandEnv(v)
 }
 
-// unmarshal calls either json.Unmarshal or yaml.Unmarshal
-// depending on configuration file name extension.
-func unmarshal(format string, data []byte, v interface{}) error {
-	if format == ".json" {
-		return json.Unmarshal(data, v)
-	} else if format == ".yml" || format == ".yaml" {
-		return yaml.Unmarshal(data, v)
+// maxEnvPasses is the maximum number of passes done by expandEnv when
+// recursive expansion is enabled.
+const maxEnvPasses = 10
+
+// expandEnv replaces v by the value of the environment variable it
+// references. If recursive expansion is enabled, the result is expanded
+// again until it doesn't reference an environment variable anymore, and
+// an error is returned if it still does after maxEnvPasses passes.
+func (o *options) expandEnv(v string) (string, error) {
+	if !o.recursiveEnv {
+		return getEnvValue(v), nil
+	}
+	res := v
+	for i := 0; i < maxEnvPasses; i++ {
+		if !strings.HasPrefix(res, "$") {
+			return res, nil
+		}
+		res = getEnvValue(res)
 	}
-	return errors.New("Unrecognized file format  " + format)
+	return "", errors.New("Environment variable expansion of " + v + " exceeds " + strconv.Itoa(maxEnvPasses) + " passes")
 }
 
 // getEnvValue cleans env var value if v is in the form ${xxx} or $xxx.
+// With the form ${xxx:-default}, default is returned if the variable is
+// unset or empty.
 func getEnvValue(v string) string {
+	if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") && strings.Contains(v, ":-") {
+		name := v[2 : len(v)-1]
+		i := strings.Index(name, ":-")
+		if env := os.Getenv(name[:i]); env != "" {
+			return env
+		}
+		return name[i+2:]
+	}
 	if strings.HasPrefix(v, "$") {
 		v = strings.Replace(v, "$", "", -1)
 		v = strings.Replace(v, "{", "", -1)
@@ -355,23 +3914,146 @@ func getEnvValue(v string) string {
 	return v
 }
 
-// readfile checks  if the provided filename is a  valid path to
+// loadDotenv sets the environment variables defined in the .env file
+// filename, unless they are already set. A missing file is ignored.
+func loadDotenv(filename string) error {
+	blob, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	vars, err := parseDotenv(blob)
+	if err != nil {
+		return err
+	}
+	for k, v := range vars {
+		if _, ok := os.LookupEnv(k); !ok {
+			os.Setenv(k, v)
+		}
+	}
+	return nil
+}
+
+// parseDotenv parses the KEY=value lines of a .env file. Blank lines and
+// lines starting with # are ignored, an optional "export " prefix is
+// allowed and values can be enclosed in single or double quotes.
+func parseDotenv(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		idx := strings.Index(line, "=")
+		if idx <= 0 {
+			return nil, errors.New("Invalid line " + strconv.Itoa(i+1) + " in .env file: missing =")
+		}
+		k, v := strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:])
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// parseINI parses the key=value lines of an .ini or a .properties file,
+// depending on format. Keys can also be separated from values by a colon.
+// In .ini files, the keys that follow a [section] header are prefixed with
+// "section.", and the ones before any header are at the top level. Blank
+// lines and lines starting with # or ; (.ini) or ! (.properties) are
+// ignored, and values can be enclosed in single or double quotes.
+func parseINI(data []byte, format string) (map[string]string, error) {
+	vars := make(map[string]string)
+	comments := "#!"
+	if format == ".ini" {
+		comments = "#;"
+	}
+	section := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.ContainsAny(line[:1], comments) {
+			continue
+		}
+		if format == ".ini" && strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || len(strings.TrimSpace(line[1:len(line)-1])) == 0 {
+				return nil, errors.New("Invalid line " + strconv.Itoa(i+1) + " in " + format + " file: malformed section header")
+			}
+			section = strings.TrimSpace(line[1:len(line)-1]) + "."
+			continue
+		}
+		idx := strings.IndexAny(line, "=:")
+		if idx <= 0 {
+			return nil, errors.New("Invalid line " + strconv.Itoa(i+1) + " in " + format + " file: missing =")
+		}
+		k, v := strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:])
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		vars[section+k] = v
+	}
+	return vars, nil
+}
+
+// stdin is the reader used when the filename given to Load is "-".
+var stdin io.Reader = os.Stdin
+
+// sniffFormat guesses the format of data, which has no file name
+// extension: JSON if it starts with { or [, YAML otherwise. The result
+// is a file name extension usable by unmarshal.
+func sniffFormat(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && (data[0] == '{' || data[0] == '[') {
+		return ".json"
+	}
+	return ".yaml"
+}
+
+// contentTypeFormat returns the file name extension of the format of media
+// type contentType, or an empty string if it is not a known configuration
+// format.
+func contentTypeFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json", "text/json":
+		return ".json"
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return ".yaml"
+	case "application/hcl":
+		return ".hcl"
+	}
+	return ""
+}
+
+// findFile checks  if the provided filename is a  valid path to
 // the file. If it is not, it checks if the filename corresponds
-// to a file relative to the executable directory. It then reads
-// the file and returns its content.
-func readFile(filename string) ([]byte, error) {
+// to a file relative to the executable directory. It returns the
+// path of the file found.
+func findFile(filename string) (string, error) {
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		absPath, err := os.Executable()
 		if err != nil {
-			return []byte{}, err
+			return "", err
 		}
 		filename = filepath.Dir(absPath) + string(os.PathSeparator) + filename
 		if _, err := os.Stat(filename); os.IsNotExist(err) {
-			return []byte{}, err
+			return "", err
 		}
 	}
+	return filename, nil
+}
+
+// readfile finds the file with findFile, then reads it and
+// returns its content.
+func readFile(filename string) ([]byte, error) {
+	filename, err := findFile(filename)
+	if err != nil {
+		return []byte{}, err
+	}
 	if fi, _ := os.Stat(filename); fi.Size() == 0 {
-		return []byte{}, errors.New("Configuration file is empty")
+		return []byte{}, ErrEmptyConfig
 	}
 	return ioutil.ReadFile(filename)
 }
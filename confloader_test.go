@@ -5,9 +5,21 @@
 package confloader
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -95,6 +107,17 @@ func TestLoad(t *testing.T) {
 				"paramObj.paramDurationArray": []string{"10h10m", "10h20m", "10h30m"}, "paramObj.paramDurationArray.0": "10h10m", "paramObj.paramDurationArray.1": "10h20m", "paramObj.paramDurationArray.2": "10h30m",
 			},
 			wantErr: false,
+		}, {
+			name: "Load Complex HCL File",
+			args: args{filename: "complex-conf.hcl"},
+			want: Config{
+				"paramString": "foo", "paramInt": 42.0, "paramFloat": 42.1, "paramBool": true, "paramDuration": "10h10m",
+				"paramObj.paramIntArray": []float64{0, 1, 2}, "paramObj.paramIntArray.0": 0.0, "paramObj.paramIntArray.1": 1.0, "paramObj.paramIntArray.2": 2.0,
+				"paramObj.paramStringArray": []string{"foo", "bar", "baz"}, "paramObj.paramStringArray.0": "foo", "paramObj.paramStringArray.1": "bar", "paramObj.paramStringArray.2": "baz",
+				"paramObj.paramNested.paramBool": false,
+				"resource.aws_s3.x.bucket":       "foo", "resource.aws_s3.y.bucket": "bar", "resource.aws_s3.y.versioning": true,
+			},
+			wantErr: false,
 		}, {
 			name: "Load JSON File With Environment Variables",
 			args: args{filename: "conf-withenv.json"},
@@ -118,13 +141,16 @@ func TestLoad(t *testing.T) {
 			want: Config{
 				"paramString": "baz", "paramInt": 42.0, "paramFloat": 42.1, "paramBool": true, "paramDuration": "10h10m",
 				"paramArray": []float64{4.0, 5.0, 6.0}, "paramArray.0": 4.0, "paramArray.1": 5.0, "paramArray.2": 6.0,
-				"paramObject.param2": "bar",
+				"paramObject.param1": "foo", "paramObject.param2": "bar",
 			},
 			wantErr: false,
 		}, {
-			name:    "Load JSON File With Null Values",
-			args:    args{filename: "conf-withnull.json"},
-			want:    Config{},
+			name: "Load JSON File With Null Values",
+			args: args{filename: "conf-withnull.json"},
+			want: Config{
+				"paramString": nil, "paramInt": nil, "paramFloat": nil, "paramBool": nil, "paramDuration": nil,
+				"paramArray.0": nil, "paramArray.1": nil, "paramArray.2": nil,
+			},
 			wantErr: false,
 		}, {
 			name: "Load YAML File With Duplicate",
@@ -132,13 +158,16 @@ func TestLoad(t *testing.T) {
 			want: Config{
 				"paramString": "baz", "paramInt": 42.0, "paramFloat": 42.1, "paramBool": true, "paramDuration": "10h10m",
 				"paramArray": []float64{4, 5, 6}, "paramArray.0": 4.0, "paramArray.1": 5.0, "paramArray.2": 6.0,
-				"paramObject.param2": "bar",
+				"paramObject.param1": "foo", "paramObject.param2": "bar",
 			},
 			wantErr: false,
 		}, {
-			name:    "Load YAML File With Null Values",
-			args:    args{filename: "conf-withnull.yaml"},
-			want:    Config{},
+			name: "Load YAML File With Null Values",
+			args: args{filename: "conf-withnull.yaml"},
+			want: Config{
+				"paramString": nil, "paramInt": nil, "paramFloat": nil, "paramBool": nil, "paramDuration": nil,
+				"paramEmpty": nil,
+			},
 			wantErr: false,
 		},
 	}
@@ -155,431 +184,5271 @@ func TestLoad(t *testing.T) {
 	}
 }
 
-func TestConfig_GetString(t *testing.T) {
-	type args struct {
-		p string
+func TestOnLoad(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+	defer func() { OnLoad = nil }()
+
+	var gotFilenames []string
+	var gotConfigs []Config
+	OnLoad = func(filename string, c Config) {
+		gotFilenames = append(gotFilenames, filename)
+		gotConfigs = append(gotConfigs, c)
+	}
+
+	c, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := Load("non-existent-file.json"); err == nil {
+		t.Fatalf("Load() error = %v, wantErr true", err)
+	}
+	b, err := LoadFromBytes([]byte(`{"paramString": "foo"}`), ".json")
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+
+	wantFilenames := []string{"complex-conf.json", ""}
+	if !reflect.DeepEqual(gotFilenames, wantFilenames) {
+		t.Errorf("OnLoad() called with %v, want %v", gotFilenames, wantFilenames)
+	}
+	wantConfigs := []Config{c, b}
+	if !reflect.DeepEqual(gotConfigs, wantConfigs) {
+		t.Errorf("OnLoad() called with %v, want %v", gotConfigs, wantConfigs)
+	}
+}
+
+func TestLoad_WithAllowEmpty(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	files := map[string]string{
+		"conf-whitespace.yaml": " \n\t\n",
+		"conf-malformed.json":  `{"paramString": }`,
 	}
+	writeTestFiles(t, files)
+
 	tests := []struct {
-		name  string
-		c     *Config
-		args  args
-		wantS string
+		filename string
+		want     Config
+		wantErr  bool
 	}{
-		{
-			name:  "Get String",
-			args:  args{p: "paramString"},
-			c:     &Config{"paramString": "foo"},
-			wantS: "foo",
-		}, {
-			name:  "Get String From Int",
-			args:  args{p: "paramInt"},
-			c:     &Config{"paramInt": 42.0},
-			wantS: "42",
-		}, {
-			name:  "Get String From Float",
-			args:  args{p: "paramFloat"},
-			c:     &Config{"paramFloat": 42.1},
-			wantS: "42.1",
-		}, {
-			name:  "Get String From Bool",
-			args:  args{p: "paramBool"},
-			c:     &Config{"paramBool": true},
-			wantS: "true",
-		}, {
-			name:  "Get String From String Array",
-			args:  args{p: "paramStringArray"},
-			c:     &Config{"paramStringArray": []string{"foo", "bar", "baz"}},
-			wantS: "foo,bar,baz",
-		}, {
-			name:  "Get String From Float Array",
-			args:  args{p: "paramFloatArray"},
-			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
-			wantS: "0.1,1.1,2.1",
-		}, {
-			name:  "Get String From Bool Array",
-			args:  args{p: "paramBoolArray"},
-			c:     &Config{"paramBoolArray": []bool{true, false, true}},
-			wantS: "true,false,true",
-		},
+		{filename: "empty.json", want: Config{}},
+		{filename: "empty.yaml", want: Config{}},
+		{filename: "conf-whitespace.yaml", want: Config{}},
+		{filename: "conf-malformed.json", want: Config{}, wantErr: true},
 	}
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if gotS := tt.c.GetString(tt.args.p); gotS != tt.wantS {
-				t.Errorf("Config.GetString() = %v, want %v", gotS, tt.wantS)
+		t.Run(tt.filename, func(t *testing.T) {
+			if _, err := Load(tt.filename); err == nil {
+				t.Errorf("Load() error = %v without option, wantErr true", err)
+			}
+			for _, opts := range [][]Option{{WithAllowEmpty()}, {WithAllowEmpty(), WithoutFlatten()}} {
+				got, err := Load(tt.filename, opts...)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Load() = %v, want %v", got, tt.want)
+				}
 			}
 		})
 	}
 }
 
-func TestConfig_GetInt(t *testing.T) {
-	type args struct {
-		p string
+func TestLoad_WithMaxFileSize(t *testing.T) {
+	defer func() { stdin = os.Stdin }()
+
+	// confMaxSize is 24 bytes long.
+	confMaxSize := `{"paramString": "foooo"}`
+	err := ioutil.WriteFile("conf-maxsize.json", []byte(confMaxSize), 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-maxsize.json")
 	}
+	defer os.Remove("conf-maxsize.json")
+
 	tests := []struct {
-		name  string
-		c     *Config
-		args  args
-		wantI int
+		name    string
+		limit   int64
+		want    Config
+		wantErr bool
 	}{
-		{
-			name:  "Get Int",
-			args:  args{p: "paramInt"},
-			c:     &Config{"paramInt": 42.0},
-			wantI: 42,
-		}, {
-			name:  "Get Float",
-			args:  args{p: "paramFloat"},
-			c:     &Config{"paramFloat": 42.1},
-			wantI: 42,
-		}, {
-			name:  "Get Bool",
-			args:  args{p: "paramBool"},
-			c:     &Config{"paramBool": true},
-			wantI: 1,
-		},
+		{name: "Load Under Limit", limit: 25, want: Config{"paramString": "foooo"}},
+		{name: "Load At Limit", limit: 24, want: Config{"paramString": "foooo"}},
+		{name: "Load Over Limit", limit: 23, want: Config{}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotI := tt.c.GetInt(tt.args.p); gotI != tt.wantI {
-				t.Errorf("Config.GetInt() = %v, want %v", gotI, tt.wantI)
+			got, err := Load("conf-maxsize.json", WithMaxFileSize(tt.limit))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+
+			stdin = strings.NewReader(confMaxSize)
+			got, err = Load("-", WithMaxFileSize(tt.limit))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v from stdin, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v from stdin, want %v", got, tt.want)
+			}
+
+			got, err = LoadFromReader(strings.NewReader(confMaxSize), ".json", WithMaxFileSize(tt.limit))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadFromReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFromReader() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestConfig_GetDuration(t *testing.T) {
-	type args struct {
-		p string
+func TestLoad_WithRootKey(t *testing.T) {
+	files := map[string]string{
+		"conf-withroot.yaml":    "app:\n  paramString: foo\n  paramObj:\n    paramArray: [1, 2]\n",
+		"conf-withoutroot.yaml": "app:\n  paramString: foo\nparamOther: bar\n",
+	}
+	writeTestFiles(t, files)
+
+	t.Run("Load Root-Wrapped Configuration", func(t *testing.T) {
+		want := Config{
+			"paramString":         "foo",
+			"paramObj.paramArray": []float64{1, 2}, "paramObj.paramArray.0": 1.0, "paramObj.paramArray.1": 2.0,
+		}
+		got, err := Load("conf-withroot.yaml", WithRootKey("app"))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Load() = %v, want %v", got, want)
+		}
+		if s := got.GetString("paramString"); s != "foo" {
+			t.Errorf("Config.GetString() = %v, want foo", s)
+		}
+	})
+
+	t.Run("Load Root-Wrapped Configuration In Order", func(t *testing.T) {
+		got, order, err := LoadOrdered("conf-withroot.yaml", WithRootKey("app"))
+		if err != nil {
+			t.Fatalf("LoadOrdered() error = %v", err)
+		}
+		want := []KeyValue{
+			{Key: "paramString", Value: "foo"},
+			{Key: "paramObj.paramArray", Value: []float64{1, 2}},
+			{Key: "paramObj.paramArray.0", Value: 1.0},
+			{Key: "paramObj.paramArray.1", Value: 2.0},
+		}
+		if kvs := got.OrderedSub("", order); !reflect.DeepEqual(kvs, want) {
+			t.Errorf("Config.OrderedSub() = %v, want %v", kvs, want)
+		}
+	})
+
+	t.Run("Load Root-Wrapped Configuration Without Flatten", func(t *testing.T) {
+		got, err := Load("conf-withroot.yaml", WithRootKey("app"), WithoutFlatten())
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if s := got.GetString("paramString"); s != "foo" {
+			t.Errorf("Config.GetString() = %v, want foo", s)
+		}
+	})
+
+	t.Run("Load Parameters Outside Root", func(t *testing.T) {
+		for _, opts := range [][]Option{{WithRootKey("app")}, {WithRootKey("app"), WithoutFlatten()}} {
+			got, err := Load("conf-withoutroot.yaml", opts...)
+			if err == nil {
+				t.Errorf("Load() error = %v, wantErr true", err)
+			}
+			if !reflect.DeepEqual(got, Config{}) {
+				t.Errorf("Load() = %v, want %v", got, Config{})
+			}
+		}
+	})
+}
+
+func TestLoad_EmptyArray(t *testing.T) {
+	files := map[string]string{
+		"conf-emptyarray.json": `{"items": [], "paramObj": {"items": []}}`,
+		"conf-emptyarray.yaml": "items: []\nparamObj:\n  items: []\n",
+		"conf-emptyarray.hcl":  "items = []\nparamObj {\n  items = []\n}\n",
+	}
+	writeTestFiles(t, files)
+
+	// the element type of an empty array is unknown, so it is not stored,
+	// and the array getters return an empty slice.
+	want := Config{}
+	for file := range files {
+		t.Run(file, func(t *testing.T) {
+			got, err := Load(file)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %v, want %v", got, want)
+			}
+			if a := got.GetIntArray("items"); len(a) != 0 {
+				t.Errorf("Config.GetIntArray() = %v, want []", a)
+			}
+		})
+	}
+}
+
+func TestLoad_DuplicateObjects(t *testing.T) {
+	files := map[string]string{
+		"conf-withnesteddup.json": `{
+    "paramObj": {"paramSub": {"param1": "foo"}, "paramString": "foo"},
+    "paramObj": {"paramSub": {"param2": "bar"}, "paramString": "bar"},
+    "paramReplaced": {"param1": "foo"},
+    "paramReplaced": "bar",
+    "paramArray": [{"param1": "foo", "param1": "bar"}]
+}`,
+		"conf-withnesteddup.yaml": `
+paramObj:
+  paramSub:
+    param1: foo
+  paramString: foo
+paramObj:
+  paramSub:
+    param2: bar
+  paramString: bar
+paramReplaced:
+  param1: foo
+paramReplaced: bar
+paramArray:
+  - param1: foo
+    param1: bar
+`,
+	}
+	writeTestFiles(t, files)
+
+	want := Config{
+		"paramObj.paramSub.param1": "foo", "paramObj.paramSub.param2": "bar", "paramObj.paramString": "bar",
+		"paramReplaced":       "bar",
+		"paramArray.0.param1": "bar",
+	}
+	for file := range files {
+		t.Run(file, func(t *testing.T) {
+			got, err := Load(file)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %v, want %v", got, want)
+			}
+			nested, err := Load(file, WithoutFlatten())
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			sub, _ := nested["paramObj"].(map[string]interface{})["paramSub"].(map[string]interface{})
+			if len(sub) != 2 {
+				t.Errorf("Load() paramObj.paramSub = %v without flatten, want 2 parameters", sub)
+			}
+		})
+	}
+}
+
+func TestLoad_Dotenv(t *testing.T) {
+	os.Setenv("ENV_DOTENV_PASSWORD", "secret")
+	files := map[string]string{
+		"conf.env": `# database settings
+DB.HOST=localhost
+DB.PORT = 5432
+
+DB.NAME="my db"
+DB.USER='admin'
+DB.PASSWORD=${ENV_DOTENV_PASSWORD}
+export DEBUG=true
+`,
+		"conf-malformed.env": "DB.HOST=localhost\nDB.PORT\n",
+	}
+	writeTestFiles(t, files)
+
+	want := Config{
+		"DB.HOST": "localhost", "DB.PORT": "5432", "DB.NAME": "my db", "DB.USER": "admin",
+		"DB.PASSWORD": "secret", "DEBUG": "true",
+	}
+	got, err := Load("conf.env")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+	if sub := got.Sub("DB"); sub.GetString("HOST") != "localhost" {
+		t.Errorf("Config.Sub() = %v, want DB.HOST as HOST", sub)
+	}
+
+	_, err = Load("conf-malformed.env")
+	if err == nil || err.Error() != "conf-malformed.env: Invalid line 2 in .env file: missing =" {
+		t.Errorf("Load() error = %v, want conf-malformed.env: Invalid line 2 in .env file: missing =", err)
+	}
+}
+
+func TestLoad_INI(t *testing.T) {
+	os.Setenv("ENV_INI_PASSWORD", "secret")
+	files := map[string]string{
+		"conf.ini": `; global settings
+name = foo
+
+[db]
+host = localhost
+port: 5432
+password = ${ENV_INI_PASSWORD}
+
+# server settings
+[server.http]
+address = "0.0.0.0"
+`,
+		"conf.properties": `# global settings
+name=foo
+! database settings
+db.host=localhost
+db.port:5432
+db.password=${ENV_INI_PASSWORD}
+server.http.address='0.0.0.0'
+`,
+		"conf-malformed.ini":        "[db]\nhost\n",
+		"conf-malformedheader.ini":  "[db\nhost = localhost\n",
+		"conf-malformed.properties": "db.host=localhost\ndb.port\n",
+	}
+	writeTestFiles(t, files)
+
+	want := Config{
+		"name":    "foo",
+		"db.host": "localhost", "db.port": "5432", "db.password": "secret",
+		"server.http.address": "0.0.0.0",
+	}
+	for _, file := range []string{"conf.ini", "conf.properties"} {
+		t.Run(file, func(t *testing.T) {
+			got, err := Load(file)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %v, want %v", got, want)
+			}
+		})
 	}
+
 	tests := []struct {
-		name  string
-		c     *Config
-		args  args
-		wantD time.Duration
+		file    string
+		wantErr string
 	}{
-		{
-			name:  "Get Duration",
-			args:  args{p: "paramDuration"},
-			c:     &Config{"paramDuration": "42µs1000ns"},
-			wantD: 43 * time.Microsecond,
-		},
+		{file: "conf-malformed.ini", wantErr: "conf-malformed.ini: Invalid line 2 in .ini file: missing ="},
+		{file: "conf-malformedheader.ini", wantErr: "conf-malformedheader.ini: Invalid line 1 in .ini file: malformed section header"},
+		{file: "conf-malformed.properties", wantErr: "conf-malformed.properties: Invalid line 2 in .properties file: missing ="},
 	}
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if gotD := tt.c.GetDuration(tt.args.p); gotD != tt.wantD {
-				t.Errorf("Config.GetDuration() = %v, want %v", gotD, tt.wantD)
+		t.Run(tt.file, func(t *testing.T) {
+			if _, err := Load(tt.file); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Load() error = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func TestConfig_GetFloat(t *testing.T) {
-	type args struct {
-		p string
+func TestLoad_WithPreserveHashInValues(t *testing.T) {
+	confWithHashYAML := []byte(`
+paramString: foo # bar
+paramQuoted: "foo # bar"
+# a comment line
+paramObj:
+  paramNested: foo#bar # baz
+paramArray:
+  - foo # bar
+  - baz
+"paramQuotedKey": foo # bar
+paramScript: |
+  a: b # c
+  d # e
+paramFolded: >- # folded
+  x # y
+paramList:
+  - script: |
+      a # b
+    other: c # d
+paramAfter: foo # bar`)
+	err := ioutil.WriteFile("conf-withhash.yaml", confWithHashYAML, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-withhash.yaml")
 	}
+	defer os.Remove("conf-withhash.yaml")
+
 	tests := []struct {
-		name  string
-		c     *Config
-		args  args
-		wantF float64
+		name string
+		opts []Option
+		want Config
 	}{
 		{
-			name:  "Get Int",
-			args:  args{p: "paramInt"},
-			c:     &Config{"paramInt": 42.0},
-			wantF: 42.0,
-		}, {
-			name:  "Get Float",
-			args:  args{p: "paramFloat"},
-			c:     &Config{"paramFloat": 42.1},
-			wantF: 42.1,
-		}, {
-			name:  "Get Bool",
-			args:  args{p: "paramBool"},
-			c:     &Config{"paramBool": true},
-			wantF: 1,
-		}, {
-			name:  "Get Float From Float Array",
-			args:  args{p: "paramFloatArray"},
-			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
-			wantF: 0.1,
+			name: "Load YAML File Without Option",
+			opts: nil,
+			want: Config{
+				"paramString": "foo", "paramQuoted": "foo # bar", "paramObj.paramNested": "foo#bar",
+				"paramArray": []string{"foo", "baz"}, "paramArray.0": "foo", "paramArray.1": "baz",
+				"paramQuotedKey": "foo", "paramScript": "a: b # c\nd # e\n", "paramFolded": "x # y",
+				"paramList.0.script": "a # b\n", "paramList.0.other": "c", "paramAfter": "foo",
+			},
 		}, {
-			name:  "Get Float From Bool Array",
-			args:  args{p: "paramBoolArray"},
-			c:     &Config{"paramBoolArray": []bool{true, false, true}},
-			wantF: 1.0,
+			name: "Load YAML File With Option",
+			opts: []Option{WithPreserveHashInValues()},
+			want: Config{
+				"paramString": "foo # bar", "paramQuoted": "foo # bar", "paramObj.paramNested": "foo#bar # baz",
+				"paramArray": []string{"foo # bar", "baz"}, "paramArray.0": "foo # bar", "paramArray.1": "baz",
+				"paramQuotedKey": "foo # bar", "paramScript": "a: b # c\nd # e\n", "paramFolded": "x # y",
+				"paramList.0.script": "a # b\n", "paramList.0.other": "c # d", "paramAfter": "foo # bar",
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotF := tt.c.GetFloat(tt.args.p); gotF != tt.wantF {
-				t.Errorf("Config.GetFloat() = %v, want %v", gotF, tt.wantF)
+			got, err := Load("conf-withhash.yaml", tt.opts...)
+			if err != nil {
+				t.Errorf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestConfig_GetBool(t *testing.T) {
-	type args struct {
-		p string
-	}
-	tests := []struct {
+func TestLoad_HCLRepeatedBlocks(t *testing.T) {
+	confRepeatedHCL := []byte(`
+server {
+  port = 1
+}
+server {
+  port = 2
+  host = "b"
+}
+logging {
+  level = "info"
+}
+logging {
+  format = "json"
+}`)
+	err := ioutil.WriteFile("conf-repeated.hcl", confRepeatedHCL, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-repeated.hcl")
+	}
+	defer os.Remove("conf-repeated.hcl")
+
+	got, err := Load("conf-repeated.hcl")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := Config{
+		"server.0.port": 1.0, "server.1.port": 2.0, "server.1.host": "b",
+		"logging.level": "info", "logging.format": "json",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+
+	nested, err := Load("conf-repeated.hcl", WithoutFlatten())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	wantNested := Config{
+		"server": []interface{}{
+			map[string]interface{}{"port": 1.0},
+			map[string]interface{}{"port": 2.0, "host": "b"},
+		},
+		"logging": map[string]interface{}{"level": "info", "format": "json"},
+	}
+	if !reflect.DeepEqual(nested, wantNested) {
+		t.Errorf("Load() = %v, want %v", nested, wantNested)
+	}
+}
+
+func TestLoad_NestedEnv(t *testing.T) {
+	os.Setenv("ENV_NESTED_URL", "postgres://localhost")
+	files := map[string]string{
+		"conf-nestedenv.json": `{"db": {"primary": {"conn": {"url": "${ENV_NESTED_URL}"}}}}`,
+		"conf-nestedenv.yaml": "db:\n  primary:\n    conn:\n      url: ${ENV_NESTED_URL}\n",
+		"conf-nestedenv.hcl":  "db {\n  primary {\n    conn {\n      url = \"${ENV_NESTED_URL}\"\n    }\n  }\n}\n",
+	}
+	writeTestFiles(t, files)
+
+	want := Config{"db.primary.conn.url": "postgres://localhost"}
+	for file := range files {
+		t.Run(file, func(t *testing.T) {
+			got, err := Load(file)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %v, want %v", got, want)
+			}
+			got, _, err = LoadOrdered(file)
+			if err != nil {
+				t.Fatalf("LoadOrdered() error = %v", err)
+			}
+			if s := got.GetString("db.primary.conn.url"); s != "postgres://localhost" {
+				t.Errorf("Config.GetString() = %v with LoadOrdered, want postgres://localhost", s)
+			}
+		})
+	}
+}
+
+func TestLoad_WithEnvJSONArrays(t *testing.T) {
+	os.Setenv("ENV_STRING_LIST", `["a", "b"]`)
+	os.Setenv("ENV_NUMBER_LIST", `[1, 2.5]`)
+	os.Setenv("ENV_NOT_LIST", `[a, b`)
+	os.Setenv("ENV_OBJECT", `{"a": 1, "b": {"c": "d"}}`)
+	os.Setenv("ENV_JSON_SCALAR", `42`)
+
+	confWithEnvListJSON := []byte(`{
+    "paramObject": "${ENV_OBJECT}",
+    "paramScalar": "${ENV_JSON_SCALAR}",
+    "paramStringList": "${ENV_STRING_LIST}",
+    "paramNumberList": "$ENV_NUMBER_LIST",
+    "paramNotList": "${ENV_NOT_LIST}",
+    "paramLiteral": "[\"c\"]"
+}`)
+	err := ioutil.WriteFile("conf-withenvlist.json", confWithEnvListJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-withenvlist.json")
+	}
+	defer os.Remove("conf-withenvlist.json")
+
+	tests := []struct {
+		name string
+		opts []Option
+		want Config
+	}{
+		{
+			name: "Load JSON File Without Option",
+			opts: nil,
+			want: Config{
+				"paramStringList": `["a", "b"]`, "paramNumberList": `[1, 2.5]`,
+				"paramNotList": `[a, b`, "paramLiteral": `["c"]`,
+				"paramObject": `{"a": 1, "b": {"c": "d"}}`, "paramScalar": "42",
+			},
+		}, {
+			name: "Load JSON File With Option",
+			opts: []Option{WithEnvJSONArrays()},
+			want: Config{
+				"paramStringList": []string{"a", "b"}, "paramStringList.0": "a", "paramStringList.1": "b",
+				"paramNumberList": []float64{1, 2.5}, "paramNumberList.0": 1.0, "paramNumberList.1": 2.5,
+				"paramNotList": `[a, b`, "paramLiteral": `["c"]`,
+				"paramObject.a": 1.0, "paramObject.b.c": "d", "paramScalar": "42",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load("conf-withenvlist.json", tt.opts...)
+			if err != nil {
+				t.Errorf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_WithCSVArrays(t *testing.T) {
+	os.Setenv("ENV_CSV_BOOL", "true,false")
+	os.Setenv("ENV_CSV_FLOAT", "1, 2.5")
+	os.Setenv("ENV_CSV_STRING", "foo, bar")
+
+	confWithCSVEnvJSON := []byte(`{
+    "paramBoolArray": "${ENV_CSV_BOOL}",
+    "paramFloatArray": "${ENV_CSV_FLOAT}",
+    "paramStringArray": "${ENV_CSV_STRING}",
+    "paramString": "foo",
+    "paramGreeting": "Hello, world"
+}`)
+	err := ioutil.WriteFile("conf-withcsvenv.json", confWithCSVEnvJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-withcsvenv.json")
+	}
+	defer os.Remove("conf-withcsvenv.json")
+
+	c, err := Load("conf-withcsvenv.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := c.GetBoolArray("paramBoolArray"); got != nil {
+		t.Errorf("Config.GetBoolArray() = %v without option, want nil", got)
+	}
+
+	c, err = Load("conf-withcsvenv.json", WithCSVArrays())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := Config{
+		"paramBoolArray": []bool{true, false}, "paramBoolArray.0": true, "paramBoolArray.1": false,
+		"paramFloatArray": []float64{1, 2.5}, "paramFloatArray.0": 1.0, "paramFloatArray.1": 2.5,
+		"paramStringArray": []string{"foo", "bar"}, "paramStringArray.0": "foo", "paramStringArray.1": "bar",
+		"paramString": "foo", "paramGreeting": "Hello, world",
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Load() = %v, want %v", c, want)
+	}
+	if got := c.GetBoolArray("paramBoolArray"); !reflect.DeepEqual(got, []bool{true, false}) {
+		t.Errorf("Config.GetBoolArray() = %v, want [true false]", got)
+	}
+	if got := c.GetString("paramStringArray"); got != "foo,bar" {
+		t.Errorf("Config.GetString() = %v, want foo,bar", got)
+	}
+}
+
+func TestLoad_WithRecursiveEnv(t *testing.T) {
+	os.Setenv("ENV_REC_A", "${ENV_REC_B}")
+	os.Setenv("ENV_REC_B", "$ENV_REC_C")
+	os.Setenv("ENV_REC_C", "foo")
+	os.Setenv("ENV_CYCLE_A", "${ENV_CYCLE_B}")
+	os.Setenv("ENV_CYCLE_B", "${ENV_CYCLE_A}")
+
+	confWithRecEnvJSON := []byte(`{
+    "paramString": "${ENV_REC_A}",
+    "paramStringArray": ["${ENV_REC_B}", "bar"]
+}`)
+	err := ioutil.WriteFile("conf-withrecenv.json", confWithRecEnvJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-withrecenv.json")
+	}
+	defer os.Remove("conf-withrecenv.json")
+
+	confWithCycleEnvJSON := []byte(`{
+    "paramString": "${ENV_CYCLE_A}"
+}`)
+	err = ioutil.WriteFile("conf-withcycleenv.json", confWithCycleEnvJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-withcycleenv.json")
+	}
+	defer os.Remove("conf-withcycleenv.json")
+
+	tests := []struct {
+		name     string
+		filename string
+		opts     []Option
+		want     Config
+		wantErr  bool
+	}{
+		{
+			name:     "Load JSON File Without Option",
+			filename: "conf-withrecenv.json",
+			opts:     nil,
+			want: Config{
+				"paramString":      "${ENV_REC_B}",
+				"paramStringArray": []string{"$ENV_REC_C", "bar"}, "paramStringArray.0": "$ENV_REC_C", "paramStringArray.1": "bar",
+			},
+		}, {
+			name:     "Load JSON File With Option",
+			filename: "conf-withrecenv.json",
+			opts:     []Option{WithRecursiveEnv()},
+			want: Config{
+				"paramString":      "foo",
+				"paramStringArray": []string{"foo", "bar"}, "paramStringArray.0": "foo", "paramStringArray.1": "bar",
+			},
+		}, {
+			name:     "Load JSON File With Cycle",
+			filename: "conf-withcycleenv.json",
+			opts:     []Option{WithRecursiveEnv()},
+			want:     Config{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.filename, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Stdin(t *testing.T) {
+	defer func() { stdin = os.Stdin }()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name:  "Load JSON From Stdin",
+			input: `{"paramString": "foo", "paramArray": [1, 2]}`,
+			want: Config{
+				"paramString": "foo",
+				"paramArray":  []float64{1, 2}, "paramArray.0": 1.0, "paramArray.1": 2.0,
+			},
+		}, {
+			name:  "Load YAML From Stdin",
+			input: "paramString: foo\nparamArray: [1, 2]\n",
+			want: Config{
+				"paramString": "foo",
+				"paramArray":  []float64{1, 2}, "paramArray.0": 1.0, "paramArray.1": 2.0,
+			},
+		}, {
+			name:    "Load Empty Stdin",
+			input:   " \n",
+			want:    Config{},
+			wantErr: true,
+		}, {
+			name:    "Load Invalid JSON From Stdin",
+			input:   `{"paramString": }`,
+			want:    Config{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin = strings.NewReader(tt.input)
+			got, err := Load("-")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_UnsupportedFormat(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	_, err := Load("conf.unhandled")
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Load() error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+	var ferr *UnsupportedFormatError
+	if !errors.As(err, &ferr) || ferr.Format != ".unhandled" {
+		t.Errorf("Load() error = %#v, want an UnsupportedFormatError for .unhandled", err)
+	}
+	if want := "Unrecognized file format .unhandled"; err.Error() != want {
+		t.Errorf("Load() error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestLoad_ErrorKinds(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	t.Run("Non-Existent File", func(t *testing.T) {
+		_, err := Load("non-existent-conf.json")
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Load() error = %v, want %v", err, os.ErrNotExist)
+		}
+	})
+
+	files := map[string]string{
+		"conf-whitespace.json": "\n  \t\n",
+		"conf-whitespace.yaml": " \n\t\n",
+		"conf-whitespace.env":  "\n\n",
+	}
+	writeTestFiles(t, files)
+	for _, file := range []string{"empty.json", "empty.yaml", "conf-whitespace.json", "conf-whitespace.yaml", "conf-whitespace.env"} {
+		t.Run("Empty File "+file, func(t *testing.T) {
+			got, err := Load(file)
+			if err != ErrEmptyConfig {
+				t.Errorf("Load() error = %v, want %v", err, ErrEmptyConfig)
+			}
+			if !reflect.DeepEqual(got, Config{}) {
+				t.Errorf("Load() = %v, want %v", got, Config{})
+			}
+		})
+	}
+
+	for _, file := range []string{"invalid-conf.json", "invalid-conf.yaml"} {
+		t.Run("Invalid File "+file, func(t *testing.T) {
+			_, err := Load(file)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Load() error = %#v, want a ParseError", err)
+			}
+			if perr.Filename != file || perr.Err == nil {
+				t.Errorf("ParseError = %#v, want Filename %v and the decoder error", perr, file)
+			}
+			if errors.Is(err, os.ErrNotExist) || errors.Is(err, ErrEmptyConfig) {
+				t.Errorf("Load() error = %v, want only a ParseError", err)
+			}
+		})
+	}
+
+	t.Run("Invalid Data", func(t *testing.T) {
+		_, err := LoadFromBytes([]byte("{"), ".json")
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Filename != "" {
+			t.Errorf("LoadFromBytes() error = %#v, want a ParseError without file name", err)
+		}
+	})
+}
+
+func TestLoadURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/conf.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"paramString": "foo", "paramObj": {"paramInt": 42}}`)
+	})
+	mux.HandleFunc("/conf.yaml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "paramString: foo\nparamObj:\n  paramInt: 42\n")
+	})
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-yaml; charset=utf-8")
+		fmt.Fprint(w, "paramString: foo\nparamObj:\n  paramInt: 42\n")
+	})
+	mux.HandleFunc("/slow.json", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	want := Config{"paramString": "foo", "paramObj.paramInt": 42.0}
+	tests := []struct {
+		name    string
+		url     string
+		want    Config
+		wantErr string
+	}{
+		{name: "Load JSON URL", url: server.URL + "/conf.json", want: want},
+		{name: "Load YAML URL", url: server.URL + "/conf.yaml?version=2", want: want},
+		{name: "Load URL With Content Type", url: server.URL + "/config", want: want},
+		{name: "Load Missing URL", url: server.URL + "/missing.json", want: Config{}, wantErr: "Could not load " + server.URL + "/missing.json: 404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadURL(tt.url)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("LoadURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Load URL With Timeout", func(t *testing.T) {
+		client := &http.Client{Timeout: 50 * time.Millisecond}
+		if _, err := LoadURL(server.URL+"/slow.json", WithHTTPClient(client)); err == nil {
+			t.Errorf("LoadURL() error = nil, want a timeout")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := LoadURLContext(ctx, server.URL+"/slow.json"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("LoadURLContext() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
+
+func TestLoadStrict(t *testing.T) {
+	files := map[string]string{
+		"conf-strict.json":         `{"paramString": "foo", "server": {"host": "a", "port": 80}, "paramArray": [1, 2]}`,
+		"conf-strict-dup.json":     `{"paramString": "foo", "paramString": "bar", "server": {"port": 80, "port": 81, "port": 82}}`,
+		"conf-strict-dup.yaml":     "paramString: foo\nserver:\n  port: 80\nparamString: bar\n",
+		"conf-strict-unknown.yaml": "paramString: foo\nparamTypo: bar\nserver:\n  hots: a\n",
+		"conf-strict-arrdup.yaml":  "servers:\n- host: a\n  host: b\n",
+	}
+	writeTestFiles(t, files)
+
+	tests := []struct {
+		name    string
+		file    string
+		allowed []string
+		wantErr string
+	}{
+		{
+			name:    "Load Expected Keys",
+			file:    "conf-strict.json",
+			allowed: []string{"paramString", "server", "paramArray"},
+		}, {
+			name:    "Load Unexpected Keys",
+			file:    "conf-strict.json",
+			allowed: []string{"paramString", "server.host"},
+			wantErr: "Configuration has unknown parameters: paramArray, server.port",
+		}, {
+			name:    "Load Duplicate JSON Keys",
+			file:    "conf-strict-dup.json",
+			allowed: []string{"paramString", "server"},
+			wantErr: "Configuration has duplicate keys: paramString, server.port",
+		}, {
+			name:    "Load Duplicate YAML Keys",
+			file:    "conf-strict-dup.yaml",
+			allowed: []string{"paramString", "server"},
+			wantErr: "Configuration has duplicate keys: paramString",
+		}, {
+			name:    "Load Duplicate YAML Keys In Array",
+			file:    "conf-strict-arrdup.yaml",
+			allowed: []string{"servers"},
+			wantErr: "Configuration has duplicate keys: servers.0.host",
+		}, {
+			name:    "Load Unexpected YAML Keys",
+			file:    "conf-strict-unknown.yaml",
+			allowed: []string{"paramString", "server.host"},
+			wantErr: "Configuration has unknown parameters: paramTypo, server.hots",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadStrict(tt.file, tt.allowed)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("LoadStrict() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := Load(tt.file); err != nil {
+				t.Errorf("Load() error = %v, want files accepted without strict mode", err)
+			}
+		})
+	}
+}
+
+func TestMustLoad(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	want, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := MustLoad("complex-conf.json"); !reflect.DeepEqual(got, want) {
+		t.Errorf("MustLoad() = %v, want %v", got, want)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("MustLoad() didn't panic for a missing file")
+		}
+	}()
+	MustLoad("non-existent-file.json")
+}
+
+func TestLoadFromBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		want    Config
+		wantErr string
+	}{
+		{
+			name:   "Load JSON",
+			data:   `{"paramString": "foo", "paramArray": [1, 2]}`,
+			format: ".json",
+			want: Config{
+				"paramString": "foo",
+				"paramArray":  []float64{1, 2}, "paramArray.0": 1.0, "paramArray.1": 2.0,
+			},
+		}, {
+			name:   "Load YAML",
+			data:   "paramString: foo\nparamObj:\n  paramBool: true\n",
+			format: ".yml",
+			want:   Config{"paramString": "foo", "paramObj.paramBool": true},
+		}, {
+			name:    "Load Empty Data",
+			data:    "",
+			format:  ".yaml",
+			want:    Config{},
+			wantErr: "Configuration file is empty",
+		}, {
+			name:    "Load Unrecognized Format",
+			data:    "paramString = foo",
+			format:  ".toml",
+			want:    Config{},
+			wantErr: "Unrecognized file format .toml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadFromBytes([]byte(tt.data), tt.format)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("LoadFromBytes() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFromBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	for _, filename := range []string{"complex-conf.json", "complex-conf.yaml", "complex-conf.hcl"} {
+		t.Run(filename, func(t *testing.T) {
+			want, err := Load(filename)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			f, err := os.Open(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := LoadFromReader(f, filepath.Ext(filename))
+			if err != nil {
+				t.Fatalf("LoadFromReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadFromReader() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		format string
+	}{
+		{name: "Load Empty Input", input: " \n", format: ".json"},
+		{name: "Load Invalid JSON", input: `{"paramString": }`, format: ".json"},
+		{name: "Load Unrecognized Format", input: "paramString = foo", format: ".toml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadFromReader(strings.NewReader(tt.input), tt.format)
+			if err == nil {
+				t.Errorf("LoadFromReader() error = nil, want error")
+			}
+			if !reflect.DeepEqual(got, Config{}) {
+				t.Errorf("LoadFromReader() = %v, want %v", got, Config{})
+			}
+		})
+	}
+}
+
+func TestLoadWithSchema_Sensitive(t *testing.T) {
+	files := map[string]string{
+		"conf-sensitive.json": `{
+    "paramInt": "42",
+    "paramString": "secret",
+    "paramObj": {"paramKey": "secret", "paramArray": ["secret"]},
+    "paramPublic": "foo"
+}`,
+		"schema-sensitive.json": `{
+    "paramInt": "int",
+    "paramString": {"type": "string", "sensitive": true},
+    "paramObj": {"sensitive": true}
+}`,
+	}
+	writeTestFiles(t, files)
+
+	c, err := LoadWithSchema("conf-sensitive.json", "schema-sensitive.json")
+	if err != nil {
+		t.Fatalf("LoadWithSchema() error = %v", err)
+	}
+	if got := c.GetString("paramString"); got != "secret" {
+		t.Errorf("Config.GetString() = %v, want secret", got)
+	}
+	if got := c.GetStringArray("paramObj.paramArray"); !reflect.DeepEqual(got, []string{"secret"}) {
+		t.Errorf("Config.GetStringArray() = %v, want [secret]", got)
+	}
+
+	wantString := "paramInt=42\nparamObj.paramArray=******\nparamObj.paramKey=******\nparamPublic=foo\nparamString=******"
+	if got := c.String(); got != wantString {
+		t.Errorf("Config.String() = %q, want %q", got, wantString)
+	}
+	copied := make(Config)
+	for k, v := range c {
+		copied[k] = v
+	}
+	if got := copied.String(); got != wantString {
+		t.Errorf("Config.String() = %q on a copy, want %q", got, wantString)
+	}
+	if got := fmt.Sprint(c["paramString"]); got != redacted {
+		t.Errorf("fmt.Sprint() = %v, want %v", got, redacted)
+	}
+	wantEnv := []string{"PARAMINT=42", "PARAMOBJ_PARAMARRAY=******", "PARAMOBJ_PARAMKEY=******", "PARAMPUBLIC=foo", "PARAMSTRING=******"}
+	if got := c.ToEnv(""); !reflect.DeepEqual(got, wantEnv) {
+		t.Errorf("Config.ToEnv() = %v, want %v", got, wantEnv)
+	}
+}
+
+func TestLoadWithEmbeddedDefault(t *testing.T) {
+	defaults := []byte("paramString: foo\nparamInt: 42\nparamObj:\n  paramBool: true\n")
+	err := ioutil.WriteFile("conf-user.json", []byte(`{"paramInt": 43, "paramObj": {"paramFloat": 42.1}}`), 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-user.json")
+	}
+	defer os.Remove("conf-user.json")
+
+	tests := []struct {
+		name     string
+		userFile string
+		want     Config
+	}{
+		{
+			name:     "Load User File Overriding Defaults",
+			userFile: "conf-user.json",
+			want:     Config{"paramString": "foo", "paramInt": 43.0, "paramObj.paramBool": true, "paramObj.paramFloat": 42.1},
+		}, {
+			name:     "Load Missing User File",
+			userFile: "non-existent-file.json",
+			want:     Config{"paramString": "foo", "paramInt": 42.0, "paramObj.paramBool": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadWithEmbeddedDefault(defaults, ".yaml", tt.userFile)
+			if err != nil {
+				t.Fatalf("LoadWithEmbeddedDefault() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadWithEmbeddedDefault() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLayered(t *testing.T) {
+	confLayeredJSON := []byte(`{
+    "server": {"host": "file.local", "port": 8080, "tags": ["a", "b"]},
+    "paramFile": "file"
+}`)
+	err := ioutil.WriteFile("conf-layered.json", confLayeredJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-layered.json")
+	}
+	defer os.Remove("conf-layered.json")
+
+	os.Setenv("LAYERED_SERVER_PORT", "9090")
+	os.Setenv("LAYERED_SERVER_TAGS", "c")
+	os.Setenv("LAYERED_PARAMDEFAULT", "env")
+	defer os.Unsetenv("LAYERED_SERVER_PORT")
+	defer os.Unsetenv("LAYERED_SERVER_TAGS")
+	defer os.Unsetenv("LAYERED_PARAMDEFAULT")
+
+	defaults := Config{
+		"server.host": "default.local", "server.port": 80.0, "server.timeout": "10s",
+		"paramDefault": "default",
+	}
+	want := Config{
+		"server.host": "file.local", "server.port": 9090.0, "server.timeout": "10s",
+		"server.tags": "c", "paramFile": "file", "paramDefault": "env",
+	}
+
+	got, err := LoadLayered("conf-layered.json", defaults, "LAYERED_")
+	if err != nil {
+		t.Fatalf("LoadLayered() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadLayered() = %v, want %v", got, want)
+	}
+	if got.GetInt("server.port") != 9090 {
+		t.Errorf("Config.GetInt() = %v, want 9090", got.GetInt("server.port"))
+	}
+	if defaults.GetString("server.host") != "default.local" {
+		t.Errorf("LoadLayered() changed defaults to %v", defaults)
+	}
+	if _, err := LoadLayered("non-existent-conf.json", defaults, "LAYERED_"); err == nil {
+		t.Errorf("LoadLayered() error = %v, wantErr true", err)
+	}
+
+	got, err = LoadLayered("conf-layered.json", Config{"Server/Timeout": "10s"}, "LAYERED_", WithDelimiter("/"), WithCaseInsensitiveKeys())
+	if err != nil {
+		t.Fatalf("LoadLayered() error = %v", err)
+	}
+	want = Config{
+		"server/host": "file.local", "server/port": 9090.0, "server/timeout": "10s",
+		"server/tags": "c", "paramfile": "file",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadLayered() = %v, want %v", got, want)
+	}
+}
+
+func TestConfig_BindEnv(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	os.Setenv("BIND_PARAMINT", "43")
+	os.Setenv("BIND_PARAMSTRING", "bar")
+	os.Setenv("BIND_PARAMBOOL", "not a boolean")
+	defer os.Unsetenv("BIND_PARAMINT")
+	defer os.Unsetenv("BIND_PARAMSTRING")
+	defer os.Unsetenv("BIND_PARAMBOOL")
+
+	c, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := c.GetFloat("paramFloat")
+	c.BindEnv("BIND_")
+	if got := c.GetInt("paramInt"); got != 43 {
+		t.Errorf("Config.GetInt() = %v, want 43", got)
+	}
+	if got := c.GetString("paramString"); got != "bar" {
+		t.Errorf("Config.GetString() = %v, want bar", got)
+	}
+	if got := c.Get("paramBool"); got != "not a boolean" {
+		t.Errorf("Config.Get() = %v, want the value of the variable", got)
+	}
+	if got := c.GetFloat("paramFloat"); got != want {
+		t.Errorf("Config.GetFloat() = %v, want %v unchanged", got, want)
+	}
+
+	os.Setenv("BIND_SERVER_PORT", "9090")
+	defer os.Unsetenv("BIND_SERVER_PORT")
+	d, err := LoadFromBytes([]byte(`{"server": {"port": 80}}`), ".json", WithDelimiter("/"))
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+	d.BindEnv("BIND_")
+	if got := d.GetInt("server/port"); got != 9090 {
+		t.Errorf("Config.GetInt() = %v, want 9090", got)
+	}
+}
+
+func TestLoadWithDotenv(t *testing.T) {
+	os.Setenv("ENV_DOTENV_REAL", "real")
+	defer os.Unsetenv("ENV_DOTENV_REAL")
+	defer os.Unsetenv("ENV_DOTENV_DIR")
+	defer os.Unsetenv("ENV_DOTENV_CWD")
+
+	err := os.Mkdir("conf-dotenv", 0755)
+	if err != nil {
+		t.Fatal("Could not generate test directory conf-dotenv")
+	}
+	defer os.RemoveAll("conf-dotenv")
+	files := map[string]string{
+		"conf-dotenv/conf.json": `{"paramDir": "${ENV_DOTENV_DIR}", "paramCwd": "${ENV_DOTENV_CWD}", "paramReal": "${ENV_DOTENV_REAL}"}`,
+		"conf-dotenv/.env":      "# comment\n\nENV_DOTENV_DIR=\"dir\"\nENV_DOTENV_REAL=dotenv\n",
+		".env":                  "export ENV_DOTENV_CWD=cwd\nENV_DOTENV_DIR=cwd\n",
+	}
+	writeTestFiles(t, files)
+
+	want := Config{"paramDir": "dir", "paramCwd": "cwd", "paramReal": "real"}
+	got, err := LoadWithDotenv("conf-dotenv/conf.json")
+	if err != nil {
+		t.Fatalf("LoadWithDotenv() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadWithDotenv() = %v, want %v", got, want)
+	}
+
+	err = ioutil.WriteFile("conf-dotenv/.env", []byte("ENV_DOTENV_INVALID\n"), 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-dotenv/.env")
+	}
+	if _, err := LoadWithDotenv("conf-dotenv/conf.json"); err == nil {
+		t.Errorf("LoadWithDotenv() error = %v, wantErr true", err)
+	}
+}
+
+func TestEditFile(t *testing.T) {
+	confCommentedYAML := `# server settings
+server:
+  # listening port
+  port: 80 # default port
+  host: 'localhost'
+  name: "foo \"bar\"" # quoted
+  tags:
+    - a
+    - b
+`
+	tests := []struct {
+		name    string
+		key     string
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Edit Number",
+			key:   "server.port",
+			value: 8080,
+			want:  strings.Replace(confCommentedYAML, "port: 80 #", "port: 8080 #", 1),
+		}, {
+			name:  "Edit Single-Quoted String",
+			key:   "server.host",
+			value: "example.com",
+			want:  strings.Replace(confCommentedYAML, "'localhost'", "example.com", 1),
+		}, {
+			name:  "Edit Double-Quoted String",
+			key:   "server.name",
+			value: "true",
+			want:  strings.Replace(confCommentedYAML, `"foo \"bar\""`, `"true"`, 1),
+		}, {
+			name:  "Edit Array Element",
+			key:   "server.tags.1",
+			value: "c: d",
+			want:  strings.Replace(confCommentedYAML, "- b", "- 'c: d'", 1),
+		}, {
+			name:  "Edit With Multi-Line String",
+			key:   "server.port",
+			value: "x\ny",
+			want:  strings.Replace(confCommentedYAML, "port: 80 #", `port: "x\ny" #`, 1),
+		}, {
+			name:    "Edit Missing Parameter",
+			key:     "server.missing",
+			value:   "foo",
+			want:    confCommentedYAML,
+			wantErr: true,
+		}, {
+			name:    "Edit Object",
+			key:     "server.tags",
+			value:   "foo",
+			want:    confCommentedYAML,
+			wantErr: true,
+		}, {
+			name:    "Edit With Non-Scalar Value",
+			key:     "server.port",
+			value:   []int{80},
+			want:    confCommentedYAML,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ioutil.WriteFile("conf-commented.yaml", []byte(confCommentedYAML), 0644)
+			if err != nil {
+				t.Fatal("Could not generate test file conf-commented.yaml")
+			}
+			defer os.Remove("conf-commented.yaml")
+
+			if err := EditFile("conf-commented.yaml", tt.key, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("EditFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got, err := ioutil.ReadFile("conf-commented.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("EditFile() wrote %q, want %q", got, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+			c, err := Load("conf-commented.yaml")
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got, want := c.GetString(tt.key), fmt.Sprint(tt.value); got != want {
+				t.Errorf("Config.GetString() = %v after edit, want %v", got, want)
+			}
+		})
+	}
+
+	if err := EditFile("complex-conf.json", "paramString", "foo"); err == nil {
+		t.Errorf("EditFile() error = %v on JSON file, wantErr true", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	err := os.Mkdir("conf-watch", 0755)
+	if err != nil {
+		t.Fatal("Could not generate test directory conf-watch")
+	}
+	defer os.RemoveAll("conf-watch")
+	write := func(file, content string) {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal("Could not generate test file " + file)
+		}
+	}
+	write("conf-watch/conf.json", `{"paramInt": 0}`)
+
+	changes := make(chan Config, 10)
+	stop, err := Watch("conf-watch/conf.json", func(c Config, err error) {
+		if err != nil {
+			t.Errorf("Watch() callback error = %v", err)
+			return
+		}
+		changes <- c
+	})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer stop()
+
+	waitChange := func(want Config) {
+		select {
+		case got := <-changes:
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Watch() reloaded %v, want %v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Watch() didn't reload, want %v", want)
+		}
+	}
+	noChange := func() {
+		select {
+		case got := <-changes:
+			t.Errorf("Watch() reloaded %v, want no reload", got)
+		case <-time.After(3 * watchDebounce):
+		}
+	}
+
+	t.Run("Debounce Successive Writes", func(t *testing.T) {
+		for i := 1; i <= 5; i++ {
+			write("conf-watch/conf.json", `{"paramInt": `+strconv.Itoa(i)+`}`)
+		}
+		waitChange(Config{"paramInt": 5.0})
+		noChange()
+	})
+
+	t.Run("Track Renamed Replacement", func(t *testing.T) {
+		write("conf-watch/conf.json.tmp", `{"paramInt": 6}`)
+		if err := os.Rename("conf-watch/conf.json.tmp", "conf-watch/conf.json"); err != nil {
+			t.Fatal(err)
+		}
+		waitChange(Config{"paramInt": 6.0})
+		write("conf-watch/conf.json", `{"paramInt": 7}`)
+		waitChange(Config{"paramInt": 7.0})
+	})
+
+	t.Run("Ignore Other Files", func(t *testing.T) {
+		write("conf-watch/other.json", `{"paramInt": 8}`)
+		noChange()
+	})
+
+	t.Run("Stop Watching", func(t *testing.T) {
+		stop()
+		write("conf-watch/conf.json", `{"paramInt": 9}`)
+		noChange()
+	})
+
+	if _, err := Watch("non-existent-file.json", func(Config, error) {}); err == nil {
+		t.Errorf("Watch() error = %v, wantErr true", err)
+	}
+}
+
+func TestLoadArray(t *testing.T) {
+	files := map[string]string{
+		"conf-array.json": `[
+    {"name": "foo", "port": 80, "tags": ["a", "b"]},
+    {"name": "bar", "port": 8080, "tls": {"enabled": true}}
+]`,
+		"conf-array.yaml": `
+- name: foo
+  port: 80
+  tags: [a, b]
+- name: bar
+  port: 8080
+  tls:
+    enabled: true`,
+		"conf-array-scalars.json": `["foo", "bar"]`,
+		"conf-array-nulls.json":   `[{"a": null}, {"b": 1}]`,
+	}
+	writeTestFiles(t, files)
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	want := []Config{
+		{"name": "foo", "port": 80.0, "tags": []string{"a", "b"}, "tags.0": "a", "tags.1": "b"},
+		{"name": "bar", "port": 8080.0, "tls.enabled": true},
+	}
+	tests := []struct {
+		name     string
+		filename string
+		want     []Config
+		wantErr  bool
+	}{
+		{
+			name:     "Load JSON Array",
+			filename: "conf-array.json",
+			want:     want,
+		}, {
+			name:     "Load YAML Array",
+			filename: "conf-array.yaml",
+			want:     want,
+		}, {
+			name:     "Load Array With Null",
+			filename: "conf-array-nulls.json",
+			want:     []Config{{"a": nil}, {"b": 1.0}},
+		}, {
+			name:     "Load Array Of Scalars",
+			filename: "conf-array-scalars.json",
+			want:     nil,
+			wantErr:  true,
+		}, {
+			name:     "Load Object",
+			filename: "simple-conf.json",
+			want:     nil,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadArray(tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadArray() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	files := map[string]string{
+		"conf-base.yaml": `
+paramString: foo
+paramInt: 42
+paramStringArray: [foo, bar]
+paramIntArray: [1, 2]`,
+		"conf-override.json": `{
+    "paramString": "baz",
+    "paramStringArray": ["baz"],
+    "paramIntArray": ["qux"]
+}`,
+	}
+	writeTestFiles(t, files)
+
+	tests := []struct {
+		name      string
+		filenames []string
+		opts      []Option
+		want      Config
+		wantErr   bool
+	}{
+		{
+			name:      "Load All With Replaced Arrays",
+			filenames: []string{"conf-base.yaml", "conf-override.json"},
+			want: Config{
+				"paramString": "baz", "paramInt": 42.0,
+				"paramStringArray": []string{"baz"}, "paramStringArray.0": "baz",
+				"paramIntArray": []string{"qux"}, "paramIntArray.0": "qux",
+			},
+		}, {
+			name:      "Load All With Appended Arrays",
+			filenames: []string{"conf-base.yaml", "conf-override.json"},
+			opts:      []Option{WithArrayMergeAppend()},
+			want: Config{
+				"paramString": "baz", "paramInt": 42.0,
+				"paramStringArray": []string{"foo", "bar", "baz"}, "paramStringArray.0": "foo", "paramStringArray.1": "bar", "paramStringArray.2": "baz",
+				"paramIntArray": []string{"qux"}, "paramIntArray.0": "qux",
+			},
+		}, {
+			name:      "Load All With Delimiter",
+			filenames: []string{"conf-base.yaml", "conf-override.json"},
+			opts:      []Option{WithDelimiter("/"), WithArrayMergeAppend()},
+			want: Config{
+				"paramString": "baz", "paramInt": 42.0,
+				"paramStringArray": []string{"foo", "bar", "baz"}, "paramStringArray/0": "foo", "paramStringArray/1": "bar", "paramStringArray/2": "baz",
+				"paramIntArray": []string{"qux"}, "paramIntArray/0": "qux",
+			},
+		}, {
+			name:      "Load All With Case-Insensitive Keys",
+			filenames: []string{"conf-base.yaml", "conf-override.json"},
+			opts:      []Option{WithCaseInsensitiveKeys()},
+			want: Config{
+				"paramstring": "baz", "paramint": 42.0,
+				"paramstringarray": []string{"baz"}, "paramstringarray.0": "baz",
+				"paramintarray": []string{"qux"}, "paramintarray.0": "qux",
+			},
+		}, {
+			name:      "Load All With Non-Existent File",
+			filenames: []string{"conf-base.yaml", "non-existent-conf.json"},
+			want:      Config{},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadAll(tt.filenames, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Get With Case-Insensitive Keys", func(t *testing.T) {
+		got, err := LoadAll([]string{"conf-base.yaml", "conf-override.json"}, WithCaseInsensitiveKeys())
+		if err != nil {
+			t.Fatalf("LoadAll() error = %v", err)
+		}
+		if i := got.GetInt("ParamInt"); i != 42 {
+			t.Errorf("Config.GetInt() = %v, want 42", i)
+		}
+		if s := got.GetString("PARAMSTRING"); s != "baz" {
+			t.Errorf("Config.GetString() = %v, want baz", s)
+		}
+	})
+}
+
+func TestLoadConcat(t *testing.T) {
+	tests := []struct {
+		name    string
+		readers []io.Reader
+		formats []string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "Load JSON And YAML",
+			readers: []io.Reader{
+				strings.NewReader(`{"paramString": "foo", "paramObj": {"paramInt": 42, "paramBool": true}}`),
+				strings.NewReader("paramObj:\n  paramInt: 43\nparamArray: [foo]\n"),
+			},
+			formats: []string{".json", ".yaml"},
+			want: Config{
+				"paramString":       "foo",
+				"paramObj.paramInt": 43.0, "paramObj.paramBool": true,
+				"paramArray": []string{"foo"}, "paramArray.0": "foo",
+			},
+		}, {
+			name:    "Load Mismatched Lengths",
+			readers: []io.Reader{strings.NewReader(`{"paramString": "foo"}`)},
+			formats: []string{".json", ".yaml"},
+			want:    Config{},
+			wantErr: true,
+		}, {
+			name:    "Load Invalid Reader",
+			readers: []io.Reader{strings.NewReader(`{"paramString": "foo"}`), strings.NewReader(`{`)},
+			formats: []string{".json", ".json"},
+			want:    Config{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadConcat(tt.readers, tt.formats)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadConcat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadConcat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Load With Delimiter", func(t *testing.T) {
+		readers := []io.Reader{
+			strings.NewReader(`{"paramArray": ["foo", "bar"]}`),
+			strings.NewReader(`{"paramArray": ["baz"]}`),
+		}
+		got, err := LoadConcat(readers, []string{".json", ".json"}, WithDelimiter("/"))
+		if err != nil {
+			t.Fatalf("LoadConcat() error = %v", err)
+		}
+		if want := (Config{"paramArray": []string{"baz"}, "paramArray/0": "baz"}); !reflect.DeepEqual(got, want) {
+			t.Errorf("LoadConcat() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestLoadDirContext(t *testing.T) {
+	err := os.Mkdir("conf-dir", 0755)
+	if err != nil {
+		t.Fatal("Could not generate test directory conf-dir")
+	}
+	defer os.RemoveAll("conf-dir")
+	files := map[string]string{
+		"conf-dir/01-base.yaml":     "paramString: foo\nparamInt: 42",
+		"conf-dir/02-override.json": `{"paramString": "bar"}`,
+		"conf-dir/README.txt":       "not a configuration file",
+	}
+	writeTestFiles(t, files)
+
+	t.Run("Load Directory", func(t *testing.T) {
+		want := Config{"paramString": "bar", "paramInt": 42.0}
+		got, err := LoadDirContext(context.Background(), "conf-dir")
+		if err != nil {
+			t.Errorf("LoadDirContext() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("LoadDirContext() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Load Directory Cancelled Mid-Load", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		// the normalizer is called while the first file is loaded.
+		cancelOnLoad := WithKeyNormalizer(func(key string) string {
+			cancel()
+			return key
+		})
+		got, err := LoadDirContext(ctx, "conf-dir", cancelOnLoad)
+		if err != context.Canceled {
+			t.Errorf("LoadDirContext() error = %v, want %v", err, context.Canceled)
+		}
+		if !reflect.DeepEqual(got, Config{}) {
+			t.Errorf("LoadDirContext() = %v, want %v", got, Config{})
+		}
+	})
+
+	t.Run("Load Non-Existent Directory", func(t *testing.T) {
+		if _, err := LoadDir("non-existent-dir"); err == nil {
+			t.Errorf("LoadDir() error = %v, wantErr true", err)
+		}
+	})
+}
+
+func TestLoadWithSchema(t *testing.T) {
+	os.Setenv("ENV_SCHEMA_INT", "42")
+	os.Setenv("ENV_SCHEMA_BOOL", "true")
+	os.Setenv("ENV_SCHEMA_INVALID", "abc")
+
+	files := map[string]string{
+		"conf-schema.json": `{
+    "paramInt": "${ENV_SCHEMA_INT}",
+    "paramFloat": "42.1",
+    "paramBool": "${ENV_SCHEMA_BOOL}",
+    "paramDuration": "1h 30m",
+    "paramString": 42,
+    "paramUntyped": "42"
+}`,
+		"conf-schema-invalid.json": `{
+    "paramInt": "${ENV_SCHEMA_INVALID}"
+}`,
+		"conf-schema-float.json": `{
+    "paramInt": 42.1
+}`,
+		"schema.json": `{
+    "paramInt": "int",
+    "paramFloat": "float",
+    "paramBool": "bool",
+    "paramDuration": "duration",
+    "paramString": "string",
+    "paramMissing": "int"
+}`,
+		"schema-unknown.json": `{
+    "paramInt": "complex"
+}`,
+	}
+	writeTestFiles(t, files)
+
+	tests := []struct {
+		name       string
+		configFile string
+		schemaFile string
+		want       Config
+		wantErr    bool
+	}{
+		{
+			name:       "Load With Schema",
+			configFile: "conf-schema.json",
+			schemaFile: "schema.json",
+			want: Config{
+				"paramInt": 42.0, "paramFloat": 42.1, "paramBool": true, "paramDuration": "1h 30m",
+				"paramString": "42", "paramUntyped": "42",
+			},
+		}, {
+			name:       "Load With Schema And Invalid Int",
+			configFile: "conf-schema-invalid.json",
+			schemaFile: "schema.json",
+			want:       Config{},
+			wantErr:    true,
+		}, {
+			name:       "Load With Schema And Float Int",
+			configFile: "conf-schema-float.json",
+			schemaFile: "schema.json",
+			want:       Config{},
+			wantErr:    true,
+		}, {
+			name:       "Load With Unknown Schema Type",
+			configFile: "conf-schema.json",
+			schemaFile: "schema-unknown.json",
+			want:       Config{},
+			wantErr:    true,
+		}, {
+			name:       "Load With Non-Existent Schema",
+			configFile: "conf-schema.json",
+			schemaFile: "non-existent-schema.json",
+			want:       Config{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadWithSchema(tt.configFile, tt.schemaFile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadWithSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadWithSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadValidated(t *testing.T) {
+	files := map[string]string{
+		"conf-valid.yaml": `
+paramString: foo
+paramInt: 42
+paramObj:
+  paramBool: true`,
+		"conf-valid.json": `{
+    "paramString": "foo",
+    "paramInt": 42,
+    "paramObj": {"paramBool": true}
+}`,
+		"conf-invalid-type.yaml": `
+paramString: foo
+paramInt: forty-two
+paramObj:
+  paramBool: 1`,
+		"conf-invalid-required.json": `{
+    "paramInt": 42
+}`,
+		"conf-validation-schema.json": `{
+    "type": "object",
+    "required": ["paramString", "paramInt"],
+    "properties": {
+        "paramString": {"type": "string"},
+        "paramInt": {"type": "integer"},
+        "paramObj": {
+            "type": "object",
+            "properties": {
+                "paramBool": {"type": "boolean"}
+            }
+        }
+    }
+}`,
+		"conf-invalid-schema.json": `{"type": 42}`,
+	}
+	writeTestFiles(t, files)
+
+	valid := Config{"paramString": "foo", "paramInt": 42.0, "paramObj.paramBool": true}
+	tests := []struct {
+		name       string
+		configFile string
+		schemaFile string
+		want       Config
+		wantErrs   []string
+	}{
+		{
+			name:       "Load Valid YAML File",
+			configFile: "conf-valid.yaml",
+			schemaFile: "conf-validation-schema.json",
+			want:       valid,
+		}, {
+			name:       "Load Valid JSON File",
+			configFile: "conf-valid.json",
+			schemaFile: "conf-validation-schema.json",
+			want:       valid,
+		}, {
+			name:       "Load File With Invalid Types",
+			configFile: "conf-invalid-type.yaml",
+			schemaFile: "conf-validation-schema.json",
+			want:       Config{},
+			wantErrs:   []string{"/paramInt: ", "/paramObj/paramBool: "},
+		}, {
+			name:       "Load File With Missing Required Field",
+			configFile: "conf-invalid-required.json",
+			schemaFile: "conf-validation-schema.json",
+			want:       Config{},
+			wantErrs:   []string{"/: ", "paramString"},
+		}, {
+			name:       "Load File With Invalid Schema",
+			configFile: "conf-valid.json",
+			schemaFile: "conf-invalid-schema.json",
+			want:       Config{},
+			wantErrs:   []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadValidated(tt.configFile, tt.schemaFile)
+			if (err != nil) != (tt.wantErrs != nil) {
+				t.Errorf("LoadValidated() error = %v, wantErr %v", err, tt.wantErrs)
+			}
+			for _, msg := range tt.wantErrs {
+				if err != nil && !strings.Contains(err.Error(), msg) {
+					t.Errorf("LoadValidated() error = %v, want it to contain %q", err, msg)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadValidated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Load Without Changing Options", func(t *testing.T) {
+		opts := make([]Option, 1, 2)
+		opts[0] = WithDelimiter("/")
+		spare := opts[:2]
+		spare[1] = WithAllowEmpty()
+		if _, err := LoadValidated("conf-valid.json", "conf-validation-schema.json", opts...); err != nil {
+			t.Fatalf("LoadValidated() error = %v", err)
+		}
+		o := newOptions(spare[1:])
+		if o.validate != nil || !o.allowEmpty {
+			t.Errorf("LoadValidated() overwrote the spare capacity of opts")
+		}
+	})
+}
+
+func TestAssemble(t *testing.T) {
+	tests := []struct {
+		name      string
+		fragments map[string]Config
+		want      Config
+	}{
+		{
+			name: "Assemble Two Fragments",
+			fragments: map[string]Config{
+				"plugin1": {"paramString": "foo", "paramObj.paramInt": 42.0},
+				"plugin2": {"paramString": "bar", "paramArray": []string{"baz"}, "paramArray.0": "baz"},
+			},
+			want: Config{
+				"plugin1.paramString": "foo", "plugin1.paramObj.paramInt": 42.0,
+				"plugin2.paramString": "bar", "plugin2.paramArray": []string{"baz"}, "plugin2.paramArray.0": "baz",
+			},
+		}, {
+			name:      "Assemble No Fragment",
+			fragments: map[string]Config{},
+			want:      Config{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Assemble(tt.fragments); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Assemble() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Assemble With Delimiter", func(t *testing.T) {
+		fragment, err := LoadFromBytes([]byte(`{"server": {"port": 80}}`), ".json", WithDelimiter("/"))
+		if err != nil {
+			t.Fatalf("LoadFromBytes() error = %v", err)
+		}
+		got := Assemble(map[string]Config{"plugin": fragment}, WithDelimiter("/"))
+		if want := (Config{"plugin/server/port": 80.0}); !reflect.DeepEqual(got, want) {
+			t.Errorf("Assemble() = %v, want %v", got, want)
+		}
+		sub := got.Sub("plugin/server", WithDelimiter("/"))
+		if port := sub.GetInt("port"); port != 80 {
+			t.Errorf("Assemble().Sub().GetInt() = %v, want 80", port)
+		}
+	})
+}
+
+func TestLoad_WithKeyNormalizer(t *testing.T) {
+	confCamelJSON := []byte(`{
+    "paramString": "foo",
+    "paramObj": {
+        "paramIntArray": [1, 2]
+    }
+}`)
+	err := ioutil.WriteFile("conf-camel.json", confCamelJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-camel.json")
+	}
+	defer os.Remove("conf-camel.json")
+
+	confCamelYAML := []byte(`
+paramString: foo
+paramObj:
+  paramIntArray: [1, 2]`)
+	err = ioutil.WriteFile("conf-camel.yaml", confCamelYAML, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-camel.yaml")
+	}
+	defer os.Remove("conf-camel.yaml")
+
+	confCollisionJSON := []byte(`{
+    "paramObj": {
+        "paramString": "foo",
+        "param_string": "bar"
+    }
+}`)
+	err = ioutil.WriteFile("conf-collision.json", confCollisionJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-collision.json")
+	}
+	defer os.Remove("conf-collision.json")
+
+	snakeCase := func(s string) string {
+		var b strings.Builder
+		for i, r := range s {
+			if r >= 'A' && r <= 'Z' {
+				if i > 0 {
+					b.WriteByte('_')
+				}
+				r += 'a' - 'A'
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		want     Config
+		wantErr  bool
+	}{
+		{
+			name:     "Load JSON File With Camel Case Keys",
+			filename: "conf-camel.json",
+			want: Config{
+				"param_string":              "foo",
+				"param_obj.param_int_array": []float64{1, 2}, "param_obj.param_int_array.0": 1.0, "param_obj.param_int_array.1": 2.0,
+			},
+		}, {
+			name:     "Load YAML File With Camel Case Keys",
+			filename: "conf-camel.yaml",
+			want: Config{
+				"param_string":              "foo",
+				"param_obj.param_int_array": []float64{1, 2}, "param_obj.param_int_array.0": 1.0, "param_obj.param_int_array.1": 2.0,
+			},
+		}, {
+			name:     "Load JSON File With Colliding Keys",
+			filename: "conf-collision.json",
+			want:     Config{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.filename, WithKeyNormalizer(snakeCase))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_WithDelimiter(t *testing.T) {
+	data := `{
+    "server": {"port": 8080, "hosts": ["foo", "bar"]},
+    "features": {"feature.v2.enabled": true}
+}`
+	got, err := LoadFromBytes([]byte(data), ".json", WithDelimiter("/"))
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+	want := Config{
+		"server/port":                 8080.0,
+		"server/hosts":                []string{"foo", "bar"},
+		"server/hosts/0":              "foo",
+		"server/hosts/1":              "bar",
+		"features/feature.v2.enabled": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFromBytes() = %v, want %v", got, want)
+	}
+	if port := got.GetInt("server/port"); port != 8080 {
+		t.Errorf("Config.GetInt() = %v, want 8080", port)
+	}
+	if !got.GetBool("features/feature.v2.enabled") {
+		t.Errorf("Config.GetBool() = false, want true")
+	}
+	if str, want := got.String(), "features/feature.v2.enabled=true\nserver/hosts=foo,bar\nserver/port=8080"; str != want {
+		t.Errorf("Config.String() = %q, want %q", str, want)
+	}
+	sub := got.Sub("server", WithDelimiter("/"))
+	if hosts := sub.GetStringArray("hosts"); !reflect.DeepEqual(hosts, []string{"foo", "bar"}) {
+		t.Errorf("Config.Sub().GetStringArray() = %v, want [foo bar]", hosts)
+	}
+
+	rooted, err := LoadFromBytes([]byte(data), ".json", WithDelimiter("/"), WithRootKey("server"))
+	if err == nil {
+		t.Errorf("LoadFromBytes() = %v, want an error for the parameters outside of the root key", rooted)
+	}
+	for _, d := range []string{"x", "_1_"} {
+		if _, err := LoadFromBytes([]byte(data), ".json", WithDelimiter(d)); err == nil {
+			t.Errorf("LoadFromBytes() error = %v with delimiter %q, wantErr true", err, d)
+		}
+	}
+}
+
+func TestLoad_WithCaseInsensitiveKeys(t *testing.T) {
+	data := "Server:\n  Port: 8080\n  max_conns: 10\n  Hosts: [a, b]\n"
+	cnf, err := LoadFromBytes([]byte(data), ".yaml", WithCaseInsensitiveKeys())
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+	if _, ok := cnf["server.port"]; !ok {
+		t.Errorf("LoadFromBytes() = %v, want lowercased keys", cnf)
+	}
+	for _, p := range []string{"Server.Port", "server.port", "SERVER.PORT"} {
+		if got := cnf.GetInt(p); got != 8080 {
+			t.Errorf("Config.GetInt(%q) = %v, want 8080", p, got)
+		}
+	}
+	if got := cnf.GetInt("server.Max_Conns"); got != 10 {
+		t.Errorf("Config.GetInt() = %v, want 10", got)
+	}
+	if cnf.Has("server.maxconns") {
+		t.Errorf("Config.Has() = true for a key differing by more than its case")
+	}
+	if got := cnf.GetStringArray("SERVER.HOSTS"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Config.GetStringArray() = %v, want [a b]", got)
+	}
+	sub := cnf.Sub("SERVER")
+	if got := sub.GetInt("PORT"); got != 8080 {
+		t.Errorf("Config.Sub().GetInt() = %v, want 8080", got)
+	}
+
+	sensitive, err := LoadFromBytes([]byte(data), ".yaml")
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+	if sensitive.Has("server.port") {
+		t.Errorf("Config.Has() = true without WithCaseInsensitiveKeys")
+	}
+
+	if _, err := LoadFromBytes([]byte(`{"Port": 1, "port": 2}`), ".json", WithCaseInsensitiveKeys()); err == nil {
+		t.Errorf("LoadFromBytes() error = nil, want a collision error")
+	}
+}
+
+func TestLoad_WithStyleInsensitiveKeys(t *testing.T) {
+	files := map[string]string{
+		"conf-styles.yaml":           "server:\n  maxConnections: 10\n  read_timeout: 5s\n  allowed-hosts: [a, b]\n",
+		"conf-styles-collision.json": `{"server": {"maxConnections": 10, "max_connections": 20}}`,
+	}
+	writeTestFiles(t, files)
+
+	cnf, err := Load("conf-styles.yaml", WithStyleInsensitiveKeys())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, p := range []string{"server.maxConnections", "server.max_connections", "server.max-connections", "Server.MaxConnections"} {
+		if got := cnf.GetInt(p); got != 10 {
+			t.Errorf("Config.GetInt(%q) = %v, want 10", p, got)
+		}
+	}
+	if got := cnf.GetDuration("server.readTimeout"); got != 5*time.Second {
+		t.Errorf("Config.GetDuration() = %v, want 5s", got)
+	}
+	if got := cnf.GetStringArray("server.allowedHosts"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Config.GetStringArray() = %v, want [a b]", got)
+	}
+	if !cnf.Has("server.read-timeout") {
+		t.Errorf("Config.Has() = false, want true")
+	}
+	sub := cnf.Sub("Server")
+	if got := sub.GetInt("max_connections"); got != 10 {
+		t.Errorf("Config.Sub().GetInt() = %v, want 10", got)
+	}
+
+	if _, err := Load("conf-styles-collision.json", WithStyleInsensitiveKeys()); err == nil {
+		t.Errorf("Load() error = nil, want a collision error")
+	}
+}
+
+func TestLoad_WithoutFlatten(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	type obj struct {
+		ParamIntArray    []int                    `json:"paramIntArray"`
+		ParamFloatArray  []float64                `json:"paramFloatArray"`
+		ParamStringArray []string                 `json:"paramStringArray"`
+		ParamBoolArray   []bool                   `json:"paramBoolArray"`
+		ParamNested      struct{ ParamBool bool } `json:"paramNested"`
+	}
+	type conf struct {
+		ParamString string  `json:"paramString"`
+		ParamInt    int     `json:"paramInt"`
+		ParamFloat  float64 `json:"paramFloat"`
+		ParamBool   bool    `json:"paramBool"`
+		ParamObj    obj     `json:"paramObj"`
+	}
+
+	for _, filename := range []string{"complex-conf.json", "complex-conf.yaml", "complex-conf.hcl"} {
+		t.Run(filename, func(t *testing.T) {
+			flat, err := Load(filename)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			nested, err := Load(filename, WithoutFlatten())
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if _, ok := nested["paramObj"].(map[string]interface{}); !ok {
+				t.Errorf("Load() paramObj = %v, want nested map", nested["paramObj"])
+			}
+			if got := nested.GetString("paramString"); got != "foo" {
+				t.Errorf("Config.GetString() = %v, want foo", got)
+			}
+
+			var fromFlat, fromNested conf
+			if err := flat.Unmarshal(&fromFlat); err != nil {
+				t.Fatalf("Config.Unmarshal() error = %v", err)
+			}
+			if err := nested.Unmarshal(&fromNested); err != nil {
+				t.Fatalf("Config.Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(fromFlat, fromNested) {
+				t.Errorf("Config.Unmarshal() = %+v without flatten, want %+v", fromNested, fromFlat)
+			}
+			if fromNested.ParamString != "foo" || fromNested.ParamInt != 42 || len(fromNested.ParamObj.ParamIntArray) != 3 {
+				t.Errorf("Config.Unmarshal() = %+v", fromNested)
+			}
+		})
+	}
+}
+
+func TestLoad_WithFileValueExpansion(t *testing.T) {
+	err := os.Mkdir("certs", 0755)
+	if err != nil {
+		t.Fatal("Could not generate test directory certs")
+	}
+	defer os.RemoveAll("certs")
+
+	files := map[string]string{
+		"certs/ca.pem": "-----BEGIN CERTIFICATE-----\nfoo\n-----END CERTIFICATE-----\n",
+		"certs/conf-withfile.json": `{
+    "paramCert": "@ca.pem",
+    "paramEscaped": "@@foo",
+    "paramArray": ["@ca.pem", "bar"]
+}`,
+		"certs/conf-withmissingfile.json": `{
+    "paramCert": "@missing.pem"
+}`,
+	}
+	writeTestFiles(t, files)
+
+	cert := files["certs/ca.pem"]
+	tests := []struct {
+		name     string
+		filename string
+		opts     []Option
+		want     Config
+		wantErr  bool
+	}{
+		{
+			name:     "Load JSON File Without Option",
+			filename: "certs/conf-withfile.json",
+			opts:     nil,
+			want: Config{
+				"paramCert": "@ca.pem", "paramEscaped": "@@foo",
+				"paramArray": []string{"@ca.pem", "bar"}, "paramArray.0": "@ca.pem", "paramArray.1": "bar",
+			},
+		}, {
+			name:     "Load JSON File With Option",
+			filename: "certs/conf-withfile.json",
+			opts:     []Option{WithFileValueExpansion()},
+			want: Config{
+				"paramCert": cert, "paramEscaped": "@foo",
+				"paramArray": []string{cert, "bar"}, "paramArray.0": cert, "paramArray.1": "bar",
+			},
+		}, {
+			name:     "Load JSON File With Missing File",
+			filename: "certs/conf-withmissingfile.json",
+			opts:     []Option{WithFileValueExpansion()},
+			want:     Config{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.filename, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Has(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	c, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		p    string
+		want bool
+	}{
+		{name: "Has Present Key", p: "paramString", want: true},
+		{name: "Has Nested Array", p: "paramObj.paramIntArray", want: true},
+		{name: "Has Array Element", p: "paramObj.paramIntArray.1", want: true},
+		{name: "Has Absent Key", p: "paramMissing", want: false},
+		{name: "Has Object", p: "paramObj", want: false},
+		{name: "Has Absent Array Element", p: "paramObj.paramIntArray.3", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Has(tt.p); got != tt.want {
+				t.Errorf("Config.Has() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	nullTests := []struct {
+		name     string
+		filename string
+		p        string
+		want     bool
+	}{
+		{name: "Has Null JSON Key", filename: "conf-withnull.json", p: "paramString", want: true},
+		{name: "Has Null JSON Array Element", filename: "conf-withnull.json", p: "paramArray.1", want: true},
+		{name: "Has Absent JSON Key", filename: "conf-withnull.json", p: "paramMissing", want: false},
+		{name: "Has Empty JSON Object", filename: "conf-withnull.json", p: "paramObject", want: false},
+		{name: "Has Null YAML Key", filename: "conf-withnull.yaml", p: "paramDuration", want: true},
+		{name: "Has Empty YAML Value", filename: "conf-withnull.yaml", p: "paramEmpty", want: true},
+		{name: "Has Absent YAML Key", filename: "conf-withnull.yaml", p: "paramMissing", want: false},
+	}
+	for _, tt := range nullTests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(tt.filename)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := c.Has(tt.p); got != tt.want {
+				t.Errorf("Config.Has() = %v, want %v", got, tt.want)
+			}
+			if got := c.Get(tt.p); got != nil {
+				t.Errorf("Config.Get() = %v, want nil", got)
+			}
+		})
+	}
+
+	t.Run("Has Null Key Under Sub", func(t *testing.T) {
+		c, err := LoadFromBytes([]byte(`{"server": {"host": null}}`), ".json")
+		if err != nil {
+			t.Fatalf("LoadFromBytes() error = %v", err)
+		}
+		sub := c.Sub("server")
+		if !sub.Has("host") {
+			t.Errorf("Config.Sub().Has() = false, want true")
+		}
+		c.Set("server.host", nil)
+		if c.Has("server.host") {
+			t.Errorf("Config.Has() = true after Set() with nil, want false")
+		}
+	})
+}
+
+func TestConfig_GetFirst(t *testing.T) {
+	c := &Config{
+		"backends.foo.host": "foo.local", "backends.foo.port": 80.0,
+		"backends.bar.host": "bar.local", "backends.bar.port": 8080.0,
+		"backendsOther.aaa": "baz",
+	}
+	tests := []struct {
+		name          string
+		patternPrefix string
+		wantKey       string
+		wantValue     interface{}
+	}{
+		{
+			name:          "Get First Backend",
+			patternPrefix: "backends",
+			wantKey:       "backends.bar.host",
+			wantValue:     "bar.local",
+		}, {
+			name:          "Get First Under Child",
+			patternPrefix: "backends.foo",
+			wantKey:       "backends.foo.host",
+			wantValue:     "foo.local",
+		}, {
+			name:          "Get First With No Match",
+			patternPrefix: "servers",
+			wantKey:       "",
+			wantValue:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotKey, gotValue := c.GetFirst(tt.patternPrefix)
+			if gotKey != tt.wantKey || gotValue != tt.wantValue {
+				t.Errorf("Config.GetFirst() = %v, %v, want %v, %v", gotKey, gotValue, tt.wantKey, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestConfig_GetString(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantS string
+	}{
+		{
+			name:  "Get String",
+			args:  args{p: "paramString"},
+			c:     &Config{"paramString": "foo"},
+			wantS: "foo",
+		}, {
+			name:  "Get String From Int",
+			args:  args{p: "paramInt"},
+			c:     &Config{"paramInt": 42.0},
+			wantS: "42",
+		}, {
+			name:  "Get String From Large Int",
+			args:  args{p: "paramInt"},
+			c:     &Config{"paramInt": 1000000.0},
+			wantS: "1000000",
+		}, {
+			name:  "Get String From Very Large Int",
+			args:  args{p: "paramInt"},
+			c:     &Config{"paramInt": 1e21},
+			wantS: "1000000000000000000000",
+		}, {
+			name:  "Get String From Float",
+			args:  args{p: "paramFloat"},
+			c:     &Config{"paramFloat": 42.1},
+			wantS: "42.1",
+		}, {
+			name:  "Get String From Bool",
+			args:  args{p: "paramBool"},
+			c:     &Config{"paramBool": true},
+			wantS: "true",
+		}, {
+			name:  "Get String From String Array",
+			args:  args{p: "paramStringArray"},
+			c:     &Config{"paramStringArray": []string{"foo", "bar", "baz"}},
+			wantS: "foo,bar,baz",
+		}, {
+			name:  "Get String From Float Array",
+			args:  args{p: "paramFloatArray"},
+			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
+			wantS: "0.1,1.1,2.1",
+		}, {
+			name:  "Get String From Bool Array",
+			args:  args{p: "paramBoolArray"},
+			c:     &Config{"paramBoolArray": []bool{true, false, true}},
+			wantS: "true,false,true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotS := tt.c.GetString(tt.args.p); gotS != tt.wantS {
+				t.Errorf("Config.GetString() = %v, want %v", gotS, tt.wantS)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringFloat(t *testing.T) {
+	type args struct {
+		p    string
+		prec int
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantS string
+	}{
+		{
+			name:  "Get Float With Precision 2",
+			args:  args{p: "paramFloat", prec: 2},
+			c:     &Config{"paramFloat": 42.1},
+			wantS: "42.10",
+		}, {
+			name:  "Get Int With Precision 2",
+			args:  args{p: "paramInt", prec: 2},
+			c:     &Config{"paramInt": 42.0},
+			wantS: "42.00",
+		}, {
+			name:  "Get Rounded Float",
+			args:  args{p: "paramFloat", prec: 1},
+			c:     &Config{"paramFloat": 42.16},
+			wantS: "42.2",
+		}, {
+			name:  "Get Float With Precision 0",
+			args:  args{p: "paramFloat", prec: 0},
+			c:     &Config{"paramFloat": 42.1},
+			wantS: "42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotS := tt.c.GetStringFloat(tt.args.p, tt.args.prec); gotS != tt.wantS {
+				t.Errorf("Config.GetStringFloat() = %v, want %v", gotS, tt.wantS)
+			}
+		})
+	}
+}
+
+func TestConfig_GetFloatLocale(t *testing.T) {
+	type args struct {
+		p          string
+		decimalSep string
+	}
+	tests := []struct {
+		name    string
+		c       *Config
+		args    args
+		wantF   float64
+		wantErr bool
+	}{
+		{
+			name:  "Get Comma Decimal",
+			args:  args{p: "paramFloat", decimalSep: ","},
+			c:     &Config{"paramFloat": "42,1"},
+			wantF: 42.1,
+		}, {
+			name:  "Get Comma Decimal With Thousands",
+			args:  args{p: "paramFloat", decimalSep: ","},
+			c:     &Config{"paramFloat": "1.234,56"},
+			wantF: 1234.56,
+		}, {
+			name:  "Get Comma Decimal With Space Thousands",
+			args:  args{p: "paramFloat", decimalSep: ","},
+			c:     &Config{"paramFloat": "1 234 567,5"},
+			wantF: 1234567.5,
+		}, {
+			name:  "Get Dot Decimal With Thousands",
+			args:  args{p: "paramFloat", decimalSep: "."},
+			c:     &Config{"paramFloat": "1,234.56"},
+			wantF: 1234.56,
+		}, {
+			name:  "Get Number",
+			args:  args{p: "paramFloat", decimalSep: ","},
+			c:     &Config{"paramFloat": 42.1},
+			wantF: 42.1,
+		}, {
+			name:    "Get Invalid Number",
+			args:    args{p: "paramFloat", decimalSep: ","},
+			c:       &Config{"paramFloat": "42,1,2"},
+			wantErr: true,
+		}, {
+			name:    "Get String",
+			args:    args{p: "paramFloat", decimalSep: ","},
+			c:       &Config{"paramFloat": "foo"},
+			wantErr: true,
+		}, {
+			name:    "Get Empty Decimal Separator",
+			args:    args{p: "paramFloat", decimalSep: ""},
+			c:       &Config{"paramFloat": "42"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotF, err := tt.c.GetFloatLocale(tt.args.p, tt.args.decimalSep)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.GetFloatLocale() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotF != tt.wantF {
+				t.Errorf("Config.GetFloatLocale() = %v, want %v", gotF, tt.wantF)
+			}
+		})
+	}
+}
+
+func TestConfig_GetInt(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantI int
+	}{
+		{
+			name:  "Get Int",
+			args:  args{p: "paramInt"},
+			c:     &Config{"paramInt": 42.0},
+			wantI: 42,
+		}, {
+			name:  "Get Float",
+			args:  args{p: "paramFloat"},
+			c:     &Config{"paramFloat": 42.1},
+			wantI: 42,
+		}, {
+			name:  "Get Bool",
+			args:  args{p: "paramBool"},
+			c:     &Config{"paramBool": true},
+			wantI: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotI := tt.c.GetInt(tt.args.p); gotI != tt.wantI {
+				t.Errorf("Config.GetInt() = %v, want %v", gotI, tt.wantI)
+			}
+		})
+	}
+}
+
+func TestConfig_GetInt64(t *testing.T) {
+	c := &Config{"paramInt": 42.0, "paramBytes": 1099511627776.0, "paramNegative": -5000000000.0, "paramBool": true}
+	tests := []struct {
+		name      string
+		p         string
+		wantInt64 int64
+		wantUint  uint64
+	}{
+		{name: "Get Int", p: "paramInt", wantInt64: 42, wantUint: 42},
+		{name: "Get Large Int", p: "paramBytes", wantInt64: 1 << 40, wantUint: 1 << 40},
+		{name: "Get Negative Int", p: "paramNegative", wantInt64: -5000000000, wantUint: 0},
+		{name: "Get Bool", p: "paramBool", wantInt64: 1, wantUint: 1},
+		{name: "Get Missing", p: "paramMissing", wantInt64: 0, wantUint: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetInt64(tt.p); got != tt.wantInt64 {
+				t.Errorf("Config.GetInt64() = %v, want %v", got, tt.wantInt64)
+			}
+			if got := c.GetUint(tt.p); got != tt.wantUint {
+				t.Errorf("Config.GetUint() = %v, want %v", got, tt.wantUint)
+			}
+		})
+	}
+}
+
+func TestConfig_GetIntE(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name    string
+		c       *Config
+		args    args
+		wantI   int
+		wantErr bool
+	}{
+		{
+			name:  "Get Int",
+			args:  args{p: "paramInt"},
+			c:     &Config{"paramInt": 42.0},
+			wantI: 42,
+		}, {
+			name:  "Get Negative Int",
+			args:  args{p: "paramInt"},
+			c:     &Config{"paramInt": -42.0},
+			wantI: -42,
+		}, {
+			name:    "Get Int Beyond Int Range",
+			args:    args{p: "paramInt"},
+			c:       &Config{"paramInt": 1e19},
+			wantErr: true,
+		}, {
+			name:    "Get Int Below Int Range",
+			args:    args{p: "paramInt"},
+			c:       &Config{"paramInt": -1e19},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotI, err := tt.c.GetIntE(tt.args.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.GetIntE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotI != tt.wantI {
+				t.Errorf("Config.GetIntE() = %v, want %v", gotI, tt.wantI)
+			}
+		})
+	}
+}
+
+func TestLoad_LargeIntegers(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{format: ".json", data: `{"id": 12345678901234567, "negativeId": -9007199254740993, "small": 42, "sci": 1.5e3, "ids": [1, 2], "bigIds": [9007199254740993, 1]}`},
+		{format: ".yaml", data: "id: 12345678901234567\nnegativeId: -9007199254740993\nsmall: 42\nsci: 1.5e+3\nids: [1, 2]\nbigIds: [9007199254740993, 1]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			c, err := LoadFromBytes([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("LoadFromBytes() error = %v", err)
+			}
+			if got := c.GetInt64("id"); got != 12345678901234567 {
+				t.Errorf("Config.GetInt64() = %v, want 12345678901234567", got)
+			}
+			if got := c.GetInt64("negativeId"); got != -9007199254740993 {
+				t.Errorf("Config.GetInt64() = %v, want -9007199254740993", got)
+			}
+			if got := c.GetString("id"); got != "12345678901234567" {
+				t.Errorf("Config.GetString() = %v, want 12345678901234567", got)
+			}
+			if got, err := c.GetIntE("id"); intSize == 64 && (err != nil || got != 12345678901234567) {
+				t.Errorf("Config.GetIntE() = %v, %v, want 12345678901234567", got, err)
+			}
+			if got := c.GetUint("id"); got != 12345678901234567 {
+				t.Errorf("Config.GetUint() = %v, want 12345678901234567", got)
+			}
+			if got := c.Get("small"); got != 42.0 {
+				t.Errorf("Config.Get() = %#v, want 42.0", got)
+			}
+			if got := c.GetInt64("sci"); got != 1500 {
+				t.Errorf("Config.GetInt64() = %v, want 1500", got)
+			}
+			if got := c.GetFloatArray("ids"); !reflect.DeepEqual(got, []float64{1, 2}) {
+				t.Errorf("Config.GetFloatArray() = %v, want [1 2]", got)
+			}
+			if got := c.GetInt64("bigIds.0"); got != 9007199254740993 {
+				t.Errorf("Config.GetInt64() = %v, want 9007199254740993", got)
+			}
+			if got := c.GetStringArray("bigIds"); !reflect.DeepEqual(got, []string{"9007199254740993", "1"}) {
+				t.Errorf("Config.GetStringArray() = %v, want [9007199254740993 1]", got)
+			}
+			if got := c.GetIntArray("bigIds"); intSize == 64 && !reflect.DeepEqual(got, []int{9007199254740993, 1}) {
+				t.Errorf("Config.GetIntArray() = %v, want [9007199254740993 1]", got)
+			}
+			if got := c.GetStringArray("id"); !reflect.DeepEqual(got, []string{"12345678901234567"}) {
+				t.Errorf("Config.GetStringArray() = %v, want [12345678901234567]", got)
+			}
+			if got := c.GetFloatArray("id"); !reflect.DeepEqual(got, []float64{12345678901234567}) {
+				t.Errorf("Config.GetFloatArray() = %v, want [1.2345678901234568e+16]", got)
+			}
+			if got := c.GetIntArray("id"); intSize == 64 && !reflect.DeepEqual(got, []int{12345678901234567}) {
+				t.Errorf("Config.GetIntArray() = %v, want [12345678901234567]", got)
+			}
+			if got := c.GetInterfaceArray("id"); !reflect.DeepEqual(got, []interface{}{json.Number("12345678901234567")}) {
+				t.Errorf("Config.GetInterfaceArray() = %v, want [12345678901234567]", got)
+			}
+			if got := c.GetStringArrayJSON("bigIds"); !reflect.DeepEqual(got, []string{"9007199254740993", "1"}) {
+				t.Errorf("Config.GetStringArrayJSON() = %v, want [9007199254740993 1]", got)
+			}
+
+			var v struct {
+				ID         int64
+				NegativeID int64
+				IDs        []int64 `confloader:"id"`
+				BigIds     []int64
+			}
+			if err := c.Unmarshal(&v); err != nil {
+				t.Fatalf("Config.Unmarshal() error = %v", err)
+			}
+			if v.ID != 12345678901234567 || v.NegativeID != -9007199254740993 {
+				t.Errorf("Config.Unmarshal() = %+v, want exact values", v)
+			}
+			if !reflect.DeepEqual(v.IDs, []int64{12345678901234567}) || !reflect.DeepEqual(v.BigIds, []int64{9007199254740993, 1}) {
+				t.Errorf("Config.Unmarshal() = %+v, want exact array values", v)
+			}
+		})
+	}
+}
+
+func TestConfig_GetE(t *testing.T) {
+	c := &Config{
+		"paramString":   "foo",
+		"paramFloat":    42.5,
+		"paramBool":     true,
+		"paramDuration": "1m30s",
+		"paramMap":      map[string]interface{}{"foo": "bar"},
+	}
+	get := map[string]func(p string) (interface{}, error){
+		"GetStringE":   func(p string) (interface{}, error) { return c.GetStringE(p) },
+		"GetIntE":      func(p string) (interface{}, error) { return c.GetIntE(p) },
+		"GetFloatE":    func(p string) (interface{}, error) { return c.GetFloatE(p) },
+		"GetBoolE":     func(p string) (interface{}, error) { return c.GetBoolE(p) },
+		"GetDurationE": func(p string) (interface{}, error) { return c.GetDurationE(p) },
+	}
+	tests := []struct {
+		method  string
+		p       string
+		want    interface{}
+		wantErr error
+	}{
+		{method: "GetStringE", p: "paramString", want: "foo"},
+		{method: "GetStringE", p: "paramFloat", want: "42.5"},
+		{method: "GetStringE", p: "paramMap", want: "", wantErr: ErrInvalidValue},
+		{method: "GetStringE", p: "paramMissing", want: "", wantErr: ErrMissingParameter},
+		{method: "GetIntE", p: "paramFloat", want: 42},
+		{method: "GetIntE", p: "paramString", want: 0, wantErr: ErrInvalidValue},
+		{method: "GetIntE", p: "paramMissing", want: 0, wantErr: ErrMissingParameter},
+		{method: "GetFloatE", p: "paramFloat", want: 42.5},
+		{method: "GetFloatE", p: "paramBool", want: 1.0},
+		{method: "GetFloatE", p: "paramString", want: 0.0, wantErr: ErrInvalidValue},
+		{method: "GetFloatE", p: "paramMissing", want: 0.0, wantErr: ErrMissingParameter},
+		{method: "GetBoolE", p: "paramBool", want: true},
+		{method: "GetBoolE", p: "paramString", want: false, wantErr: ErrInvalidValue},
+		{method: "GetBoolE", p: "paramMissing", want: false, wantErr: ErrMissingParameter},
+		{method: "GetDurationE", p: "paramDuration", want: 90 * time.Second},
+		{method: "GetDurationE", p: "paramString", want: time.Duration(0), wantErr: ErrInvalidValue},
+		{method: "GetDurationE", p: "paramMap", want: time.Duration(0), wantErr: ErrInvalidValue},
+		{method: "GetDurationE", p: "paramMissing", want: time.Duration(0), wantErr: ErrMissingParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.p, func(t *testing.T) {
+			got, err := get[tt.method](tt.p)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Config.%s() error = %v, want %v", tt.method, err, tt.wantErr)
+			}
+			var perr *ParameterError
+			if err != nil && (!errors.As(err, &perr) || perr.Key != tt.p) {
+				t.Errorf("Config.%s() error = %#v, want a ParameterError for %v", tt.method, err, tt.p)
+			}
+			if got != tt.want {
+				t.Errorf("Config.%s() = %v, want %v", tt.method, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := c.GetIntE("paramString"); err == nil || err.Error() != "Parameter paramString cannot be converted to int" {
+		t.Errorf("Config.GetIntE() error = %v, want Parameter paramString cannot be converted to int", err)
+	}
+	if _, err := c.GetIntE("paramMissing"); err == nil || err.Error() != "Parameter paramMissing is missing" {
+		t.Errorf("Config.GetIntE() error = %v, want Parameter paramMissing is missing", err)
+	}
+}
+
+func Test_floatToInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		f       float64
+		size    uint
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "Max Int32",
+			f:    math.MaxInt32,
+			size: 32,
+			want: math.MaxInt32,
+		}, {
+			name: "Min Int32",
+			f:    math.MinInt32,
+			size: 32,
+			want: math.MinInt32,
+		}, {
+			name:    "Beyond Max Int32",
+			f:       math.MaxInt32 + 1,
+			size:    32,
+			wantErr: true,
+		}, {
+			name:    "Below Min Int32",
+			f:       math.MinInt32 - 1,
+			size:    32,
+			wantErr: true,
+		}, {
+			name: "Beyond Max Int32 On 64-bit",
+			f:    math.MaxInt32 + 1,
+			size: 64,
+			want: math.MaxInt32 + 1,
+		}, {
+			name:    "Beyond Max Int64",
+			f:       math.MaxInt64,
+			size:    64,
+			wantErr: true,
+		}, {
+			name:    "NaN",
+			f:       math.NaN(),
+			size:    64,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := floatToInt(tt.f, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("floatToInt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("floatToInt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getEnvValue(t *testing.T) {
+	os.Setenv("ENV_DEFAULT_SET", "foo")
+	os.Setenv("ENV_DEFAULT_EMPTY", "")
+	tests := []struct {
+		name string
+		v    string
+		want string
+	}{
+		{name: "Get Set Variable", v: "$ENV_DEFAULT_SET", want: "foo"},
+		{name: "Get Set Variable With Braces", v: "${ENV_DEFAULT_SET}", want: "foo"},
+		{name: "Get Unset Variable", v: "${ENV_DEFAULT_UNSET}", want: ""},
+		{name: "Get Set Variable With Default", v: "${ENV_DEFAULT_SET:-localhost}", want: "foo"},
+		{name: "Get Unset Variable With Default", v: "${ENV_DEFAULT_UNSET:-localhost}", want: "localhost"},
+		{name: "Get Empty Variable With Default", v: "${ENV_DEFAULT_EMPTY:-localhost}", want: "localhost"},
+		{name: "Get Unset Variable With Empty Default", v: "${ENV_DEFAULT_UNSET:-}", want: ""},
+		{name: "Get Default With Special Characters", v: "${ENV_DEFAULT_UNSET:-http://{host}:80}", want: "http://{host}:80"},
+		{name: "Get Plain String", v: "foo:-bar", want: "foo:-bar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getEnvValue(tt.v); got != tt.want {
+				t.Errorf("getEnvValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		want    int64
+		wantErr bool
+	}{
+		{name: "Get Bytes", v: "512B", want: 512},
+		{name: "Get Kilobytes", v: "10KB", want: 10000},
+		{name: "Get Megabytes", v: "256MB", want: 256000000},
+		{name: "Get Gigabytes", v: "2GB", want: 2000000000},
+		{name: "Get Terabytes", v: "1TB", want: 1000000000000},
+		{name: "Get Petabytes", v: "1PB", want: 1000000000000000},
+		{name: "Get Kibibytes", v: "10KiB", want: 10240},
+		{name: "Get Mebibytes", v: "256MiB", want: 256 << 20},
+		{name: "Get Gibibytes", v: "1.5GiB", want: 3 << 29},
+		{name: "Get Tebibytes", v: "1TiB", want: 1 << 40},
+		{name: "Get Pebibytes", v: "1PiB", want: 1 << 50},
+		{name: "Get Lowercase Unit With Space", v: " 64 kib ", want: 65536},
+		{name: "Get Bare Number String", v: "1024", want: 1024},
+		{name: "Get Bare Number", v: 2048.0, want: 2048},
+		{name: "Get Unknown Unit", v: "10XB", wantErr: true},
+		{name: "Get Invalid Number", v: "MB", wantErr: true},
+		{name: "Get Overflowing Size", v: "10000PiB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{"paramSize": tt.v}
+			got, err := c.GetBytesE("paramSize")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.GetBytesE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Config.GetBytesE() = %v, want %v", got, tt.want)
+			}
+			if got := c.GetBytes("paramSize"); got != tt.want {
+				t.Errorf("Config.GetBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	c := &Config{}
+	if _, err := c.GetBytesE("paramMissing"); !errors.Is(err, ErrMissingParameter) {
+		t.Errorf("Config.GetBytesE() error = %v, want %v", err, ErrMissingParameter)
+	}
+}
+
+func TestConfig_GetTime(t *testing.T) {
+	c := &Config{
+		"paramTime":        "2019-03-14T15:09:26+01:00",
+		"paramDate":        "14/03/2019",
+		"paramInvalidTime": "yesterday",
+	}
+	want := time.Date(2019, 3, 14, 15, 9, 26, 0, time.FixedZone("", 3600))
+	tests := []struct {
+		name    string
+		p       string
+		layout  string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "Get RFC3339 Time", p: "paramTime", want: want},
+		{name: "Get Time With Layout", p: "paramDate", layout: "02/01/2006", want: time.Date(2019, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{name: "Get Invalid Time", p: "paramInvalidTime", wantErr: true},
+		{name: "Get Time With Wrong Layout", p: "paramDate", wantErr: true},
+		{name: "Get Missing Parameter", p: "paramMissing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got time.Time
+			if tt.layout != "" {
+				got = c.GetTimeFormat(tt.p, tt.layout)
+			} else {
+				got = c.GetTime(tt.p)
+				gotE, err := c.GetTimeE(tt.p)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Config.GetTimeE() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !gotE.Equal(tt.want) {
+					t.Errorf("Config.GetTimeE() = %v, want %v", gotE, tt.want)
+				}
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Config.GetTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetDuration(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantD time.Duration
+	}{
+		{
+			name:  "Get Duration",
+			args:  args{p: "paramDuration"},
+			c:     &Config{"paramDuration": "42µs1000ns"},
+			wantD: 43 * time.Microsecond,
+		}, {
+			name:  "Get Compound Duration",
+			args:  args{p: "paramDuration"},
+			c:     &Config{"paramDuration": "1h30m"},
+			wantD: 90 * time.Minute,
+		}, {
+			name:  "Get Space Separated Duration",
+			args:  args{p: "paramDuration"},
+			c:     &Config{"paramDuration": "1h 30m"},
+			wantD: 90 * time.Minute,
+		}, {
+			name:  "Get Comma Separated Duration",
+			args:  args{p: "paramDuration"},
+			c:     &Config{"paramDuration": "1h,30m"},
+			wantD: 90 * time.Minute,
+		}, {
+			name:  "Get Comma And Space Separated Duration",
+			args:  args{p: "paramDuration"},
+			c:     &Config{"paramDuration": " 1h, 30m "},
+			wantD: 90 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotD := tt.c.GetDuration(tt.args.p); gotD != tt.wantD {
+				t.Errorf("Config.GetDuration() = %v, want %v", gotD, tt.wantD)
+			}
+		})
+	}
+}
+
+func TestConfig_GetFloat(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantF float64
+	}{
+		{
+			name:  "Get Int",
+			args:  args{p: "paramInt"},
+			c:     &Config{"paramInt": 42.0},
+			wantF: 42.0,
+		}, {
+			name:  "Get Float",
+			args:  args{p: "paramFloat"},
+			c:     &Config{"paramFloat": 42.1},
+			wantF: 42.1,
+		}, {
+			name:  "Get Bool",
+			args:  args{p: "paramBool"},
+			c:     &Config{"paramBool": true},
+			wantF: 1,
+		}, {
+			name:  "Get Float From Float Array",
+			args:  args{p: "paramFloatArray"},
+			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
+			wantF: 0.1,
+		}, {
+			name:  "Get Float From Bool Array",
+			args:  args{p: "paramBoolArray"},
+			c:     &Config{"paramBoolArray": []bool{true, false, true}},
+			wantF: 1.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotF := tt.c.GetFloat(tt.args.p); gotF != tt.wantF {
+				t.Errorf("Config.GetFloat() = %v, want %v", gotF, tt.wantF)
+			}
+		})
+	}
+}
+
+func TestConfig_GetBool(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantB bool
+	}{
+		{
+			name:  "Get Boolean",
+			args:  args{p: "paramBool"},
+			c:     &Config{"paramBool": true},
+			wantB: true,
+		}, {
+			name:  "Get Boolean From Float",
+			args:  args{p: "paramFloat"},
+			c:     &Config{"paramFloat": 42.1},
+			wantB: true,
+		}, {
+			name:  "Get Bool From Bool Array",
+			args:  args{p: "paramBoolArray"},
+			c:     &Config{"paramBoolArray": []bool{true, false, true}},
+			wantB: true,
+		}, {
+			name:  "Get Bool From Float Array",
+			args:  args{p: "paramFloatArray"},
+			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
+			wantB: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotB := tt.c.GetBool(tt.args.p); gotB != tt.wantB {
+				t.Errorf("Config.GetBool() = %v, want %v", gotB, tt.wantB)
+			}
+		})
+	}
+}
+
+func TestConfig_GetDefault(t *testing.T) {
+	c := &Config{"paramString": "", "paramInt": 0.0, "paramFloat": 0.0, "paramBool": false, "paramDuration": "0s"}
+
+	t.Run("Get Present Zero Values", func(t *testing.T) {
+		if got := c.GetStringDefault("paramString", "foo"); got != "" {
+			t.Errorf("Config.GetStringDefault() = %v, want empty", got)
+		}
+		if got := c.GetIntDefault("paramInt", 42); got != 0 {
+			t.Errorf("Config.GetIntDefault() = %v, want 0", got)
+		}
+		if got := c.GetFloatDefault("paramFloat", 42.1); got != 0 {
+			t.Errorf("Config.GetFloatDefault() = %v, want 0", got)
+		}
+		if got := c.GetBoolDefault("paramBool", true); got != false {
+			t.Errorf("Config.GetBoolDefault() = %v, want false", got)
+		}
+		if got := c.GetDurationDefault("paramDuration", time.Second); got != 0 {
+			t.Errorf("Config.GetDurationDefault() = %v, want 0s", got)
+		}
+	})
+
+	t.Run("Get Missing Values", func(t *testing.T) {
+		if got := c.GetStringDefault("paramMissing", "foo"); got != "foo" {
+			t.Errorf("Config.GetStringDefault() = %v, want foo", got)
+		}
+		if got := c.GetIntDefault("paramMissing", 42); got != 42 {
+			t.Errorf("Config.GetIntDefault() = %v, want 42", got)
+		}
+		if got := c.GetFloatDefault("paramMissing", 42.1); got != 42.1 {
+			t.Errorf("Config.GetFloatDefault() = %v, want 42.1", got)
+		}
+		if got := c.GetBoolDefault("paramMissing", true); got != true {
+			t.Errorf("Config.GetBoolDefault() = %v, want true", got)
+		}
+		if got := c.GetDurationDefault("paramMissing", time.Second); got != time.Second {
+			t.Errorf("Config.GetDurationDefault() = %v, want 1s", got)
+		}
+	})
+
+	t.Run("Get Null Values", func(t *testing.T) {
+		c, err := LoadFromBytes([]byte("port:\ntimeout: null\n"), ".yaml")
+		if err != nil {
+			t.Fatalf("LoadFromBytes() error = %v", err)
+		}
+		if got := c.GetIntDefault("port", 8080); got != 8080 {
+			t.Errorf("Config.GetIntDefault() = %v, want 8080", got)
+		}
+		if got := c.GetDurationDefault("timeout", time.Second); got != time.Second {
+			t.Errorf("Config.GetDurationDefault() = %v, want 1s", got)
+		}
+		if _, err := c.GetIntE("port"); !errors.Is(err, ErrMissingParameter) {
+			t.Errorf("Config.GetIntE() error = %v, want ErrMissingParameter", err)
+		}
+	})
+}
+
+func TestConfig_GetDurationE(t *testing.T) {
+	c := &Config{"paramDuration": "1h 30m", "paramTypo": "10hrs"}
+	tests := []struct {
+		name    string
+		p       string
+		want    time.Duration
+		wantErr string
+	}{
+		{name: "Get Valid Duration", p: "paramDuration", want: 90 * time.Minute},
+		{name: "Get Invalid Duration", p: "paramTypo", wantErr: `Parameter paramTypo cannot be converted to duration: time: unknown unit "hrs" in duration "10hrs"`},
+		{name: "Get Missing Duration", p: "paramMissing", wantErr: "Parameter paramMissing is missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.GetDurationE(tt.p)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Config.GetDurationE() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Config.GetDurationE() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringArray(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantA []string
+	}{
+		{
+			name:  "Get String Array",
+			args:  args{p: "paramStringArray"},
+			c:     &Config{"paramStringArray": []string{"foo", "bar", "baz"}},
+			wantA: []string{"foo", "bar", "baz"},
+		}, {
+			name:  "Get String Array From Float Array",
+			args:  args{p: "paramFloatArray"},
+			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
+			wantA: []string{"0.1", "1.1", "2.1"},
+		}, {
+			name:  "Get String Array From Large Int Array",
+			args:  args{p: "paramIntArray"},
+			c:     &Config{"paramIntArray": []float64{1000000, 1e21}},
+			wantA: []string{"1000000", "1000000000000000000000"},
+		}, {
+			name:  "Get String Array From Bool Array",
+			args:  args{p: "paramBoolArray"},
+			c:     &Config{"paramBoolArray": []bool{true, false, true}},
+			wantA: []string{"true", "false", "true"},
+		}, {
+			name:  "Get String Array From String",
+			args:  args{p: "paramString"},
+			c:     &Config{"paramString": "foo"},
+			wantA: []string{"foo"},
+		}, {
+			name:  "Get String Array From Float",
+			args:  args{p: "paramFloat"},
+			c:     &Config{"paramFloat": 0.1},
+			wantA: []string{"0.1"},
+		}, {
+			name:  "Get String Array From Bool",
+			args:  args{p: "paramBool"},
+			c:     &Config{"paramBool": true},
+			wantA: []string{"true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotA := tt.c.GetStringArray(tt.args.p); !reflect.DeepEqual(gotA, tt.wantA) {
+				t.Errorf("Config.GetStringArray() = %v, want %v", gotA, tt.wantA)
+			}
+		})
+	}
+}
+
+func TestConfig_GetFloatArray(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantA []float64
+	}{
+		{
+			name:  "Get Float Array",
+			args:  args{p: "paramFloatArray"},
+			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
+			wantA: []float64{0.1, 1.1, 2.1},
+		}, {
+			name:  "Get Float Array From Bool Array",
+			args:  args{p: "paramBoolArray"},
+			c:     &Config{"paramBoolArray": []bool{true, false, true}},
+			wantA: []float64{1.0, 0.0, 1.0},
+		}, {
+			name:  "Get Float Array From Float",
+			args:  args{p: "paramFloat"},
+			c:     &Config{"paramFloat": 42.1},
+			wantA: []float64{42.1},
+		}, {
+			name:  "Get Float Array From Bool (True)",
+			args:  args{p: "paramBool"},
+			c:     &Config{"paramBool": true},
+			wantA: []float64{1.0},
+		}, {
+			name:  "Get Float Array From Bool (False)",
+			args:  args{p: "paramBool"},
+			c:     &Config{"paramBool": false},
+			wantA: []float64{0.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotA := tt.c.GetFloatArray(tt.args.p); !reflect.DeepEqual(gotA, tt.wantA) {
+				t.Errorf("Config.GetFloatArray() = %v, want %v", gotA, tt.wantA)
+			}
+		})
+	}
+}
+
+func TestConfig_GetIntArray(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name string
+		c    *Config
+		args args
+		want []int
+	}{
+		{
+			name: "Get Int Array",
+			args: args{p: "paramFloatArray"},
+			c:    &Config{"paramFloatArray": []float64{0, 1, 2}},
+			want: []int{0, 1, 2},
+		}, {
+			name: "Get Int Array From Float Array",
+			args: args{p: "paramFloatArray"},
+			c:    &Config{"paramFloatArray": []float64{0.2, 1.4, 2.6}}, // floor casting
+			want: []int{0, 1, 2},
+		}, {
+			name: "Get Int Array From Bool Array",
+			args: args{p: "paramBoolArray"},
+			c:    &Config{"paramBoolArray": []bool{true, false, true}},
+			want: []int{1, 0, 1},
+		}, {
+			name: "Get Int Array From Float",
+			args: args{p: "paramFloat"},
+			c:    &Config{"paramFloat": 42.1},
+			want: []int{42},
+		}, {
+			name: "Get Int Array From Bool (True)",
+			args: args{p: "paramBool"},
+			c:    &Config{"paramBool": true},
+			want: []int{1},
+		}, {
+			name: "Get Int Array From Bool (False)",
+			args: args{p: "paramBool"},
+			c:    &Config{"paramBool": false},
+			want: []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.GetIntArray(tt.args.p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetIntArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetIntArraySorted(t *testing.T) {
+	c := &Config{"paramIntArray": []float64{3, 1, 2}}
+	want := []int{1, 2, 3}
+	if got := c.GetIntArraySorted("paramIntArray"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Config.GetIntArraySorted() = %v, want %v", got, want)
+	}
+	if got := c.GetIntArray("paramIntArray"); !reflect.DeepEqual(got, []int{3, 1, 2}) {
+		t.Errorf("Config.GetIntArraySorted() changed stored order to %v", got)
+	}
+}
+
+func TestConfig_GetDurationArray(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name string
+		c    *Config
+		args args
+		want []time.Duration
+	}{
+		{
+			name: "Get Duration Array",
+			args: args{p: "paramDurationArray"},
+			c:    &Config{"paramDurationArray": []string{"42ns", "5m", "10h10m"}},
+			want: []time.Duration{42 * time.Nanosecond, 5 * time.Minute, 10*time.Hour + 10*time.Minute},
+		}, {
+			name: "Get Duration Array From Duration",
+			args: args{p: "paramDuration"},
+			c:    &Config{"paramDuration": "42ns"},
+			want: []time.Duration{42 * time.Nanosecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.GetDurationArray(tt.args.p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetDurationArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetBoolArray(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantA []bool
+	}{
+		{
+			name:  "Get Bool Array",
+			args:  args{p: "paramBoolArray"},
+			c:     &Config{"paramBoolArray": []bool{true, false, true}},
+			wantA: []bool{true, false, true},
+		}, {
+			name:  "Get Bool Array From Float Array",
+			args:  args{p: "paramFloatArray"},
+			c:     &Config{"paramFloatArray": []float64{0.0, 1.1, 2.1}},
+			wantA: []bool{false, true, true},
+		}, {
+			name:  "Get Bool Array From Bool",
+			args:  args{p: "paramBool"},
+			c:     &Config{"paramBool": true},
+			wantA: []bool{true},
+		}, {
+			name:  "Get Bool Array From Float (Non Zero)",
+			args:  args{p: "paramFloat"},
+			c:     &Config{"paramFloat": 42.1},
+			wantA: []bool{true},
+		}, {
+			name:  "Get Bool Array From Float (Zero)",
+			args:  args{p: "paramFloat"},
+			c:     &Config{"paramFloat": 0.0},
+			wantA: []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotA := tt.c.GetBoolArray(tt.args.p); !reflect.DeepEqual(gotA, tt.wantA) {
+				t.Errorf("Config.GetBoolArray() = %v, want %v", gotA, tt.wantA)
+			}
+		})
+	}
+}
+
+func TestConfig_ArrayGettersWithNull(t *testing.T) {
+	confWithNullArrayYAML := []byte(`
+paramArray: null
+paramEmpty:`)
+	err := ioutil.WriteFile("conf-withnullarray.yaml", confWithNullArrayYAML, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-withnullarray.yaml")
+	}
+	defer os.Remove("conf-withnullarray.yaml")
+
+	loaded, err := Load("conf-withnullarray.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	configs := map[string]*Config{
+		"Loaded Null":   &loaded,
+		"Explicit Nil":  {"paramArray": nil, "paramEmpty": nil},
+		"Missing Value": {},
+	}
+	for name, c := range configs {
+		for _, p := range []string{"paramArray", "paramEmpty"} {
+			t.Run(name+" "+p, func(t *testing.T) {
+				if got := c.GetStringArray(p); len(got) != 0 {
+					t.Errorf("Config.GetStringArray() = %v, want empty", got)
+				}
+				if got := c.GetFloatArray(p); len(got) != 0 {
+					t.Errorf("Config.GetFloatArray() = %v, want empty", got)
+				}
+				if got := c.GetIntArray(p); len(got) != 0 {
+					t.Errorf("Config.GetIntArray() = %v, want empty", got)
+				}
+				if got := c.GetDurationArray(p); len(got) != 0 {
+					t.Errorf("Config.GetDurationArray() = %v, want empty", got)
+				}
+				if got := c.GetBoolArray(p); len(got) != 0 {
+					t.Errorf("Config.GetBoolArray() = %v, want empty", got)
+				}
+				if got := c.GetCSVArray(p); len(got) != 0 {
+					t.Errorf("Config.GetCSVArray() = %v, want empty", got)
+				}
+				if got := c.GetScalarOrArray(p); len(got) != 0 {
+					t.Errorf("Config.GetScalarOrArray() = %v, want empty", got)
+				}
+			})
+		}
+	}
+}
+
+func TestConfig_GetCSVArray(t *testing.T) {
+	confWithCSVYAML := []byte(`
+paramQuoted: "foo, bar,baz"
+paramArray: ["foo,bar", "baz"]`)
+	err := ioutil.WriteFile("conf-withcsv.yaml", confWithCSVYAML, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-withcsv.yaml")
+	}
+	defer os.Remove("conf-withcsv.yaml")
+
+	confWithCSVJSON := []byte(`{
+    "paramQuoted": "foo, bar,baz",
+    "paramArray": ["foo,bar", "baz"]
+}`)
+	err = ioutil.WriteFile("conf-withcsv.json", confWithCSVJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-withcsv.json")
+	}
+	defer os.Remove("conf-withcsv.json")
+
+	tests := []struct {
+		name     string
+		filename string
+		p        string
+		wantA    []string
+	}{
+		{
+			name:     "Get Quoted YAML String",
+			filename: "conf-withcsv.yaml",
+			p:        "paramQuoted",
+			wantA:    []string{"foo", "bar", "baz"},
+		}, {
+			name:     "Get Native YAML Array",
+			filename: "conf-withcsv.yaml",
+			p:        "paramArray",
+			wantA:    []string{"foo,bar", "baz"},
+		}, {
+			name:     "Get JSON String",
+			filename: "conf-withcsv.json",
+			p:        "paramQuoted",
+			wantA:    []string{"foo", "bar", "baz"},
+		}, {
+			name:     "Get Native JSON Array",
+			filename: "conf-withcsv.json",
+			p:        "paramArray",
+			wantA:    []string{"foo,bar", "baz"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(tt.filename)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if gotA := c.GetCSVArray(tt.p); !reflect.DeepEqual(gotA, tt.wantA) {
+				t.Errorf("Config.GetCSVArray() = %v, want %v", gotA, tt.wantA)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringSliceDelim(t *testing.T) {
+	c := &Config{
+		"paramHosts":   "a, b,c",
+		"paramPath":    "/bin:/usr/bin",
+		"paramEmpty":   "",
+		"paramArray":   []string{"a,b", "c"},
+		"paramArray.0": "a,b",
+		"paramArray.1": "c",
+	}
+	tests := []struct {
+		name  string
+		p     string
+		sep   string
+		wantA []string
+	}{
+		{
+			name:  "Get Comma Separated String",
+			p:     "paramHosts",
+			sep:   ",",
+			wantA: []string{"a", "b", "c"},
+		}, {
+			name:  "Get Colon Separated String",
+			p:     "paramPath",
+			sep:   ":",
+			wantA: []string{"/bin", "/usr/bin"},
+		}, {
+			name:  "Get Native Array",
+			p:     "paramArray",
+			sep:   ",",
+			wantA: []string{"a,b", "c"},
+		}, {
+			name:  "Get Empty String",
+			p:     "paramEmpty",
+			sep:   ",",
+			wantA: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotA := c.GetStringSliceDelim(tt.p, tt.sep); !reflect.DeepEqual(gotA, tt.wantA) {
+				t.Errorf("Config.GetStringSliceDelim() = %v, want %v", gotA, tt.wantA)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringArrayReversed(t *testing.T) {
+	c := &Config{"paramStringArray": []string{"foo", "bar", "baz"}}
+	want := []string{"baz", "bar", "foo"}
+	if got := c.GetStringArrayReversed("paramStringArray"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Config.GetStringArrayReversed() = %v, want %v", got, want)
+	}
+	if got := c.GetStringArray("paramStringArray"); !reflect.DeepEqual(got, []string{"foo", "bar", "baz"}) {
+		t.Errorf("Config.GetStringArrayReversed() changed stored order to %v", got)
+	}
+	if got := c.GetStringArrayReversed("paramMissing"); got != nil {
+		t.Errorf("Config.GetStringArrayReversed() = %v, want nil", got)
+	}
+}
+
+func TestConfig_GetStringArrayTrimPrefix(t *testing.T) {
+	c := &Config{
+		"paramShared": []string{"com.example.foo", "com.example.bar"},
+		"paramMixed":  []string{"com.example.foo", "org.example.bar"},
+		"paramString": "com.example.foo",
+	}
+	tests := []struct {
+		name string
+		p    string
+		want []string
+	}{
+		{name: "Trim Shared Prefix", p: "paramShared", want: []string{"foo", "bar"}},
+		{name: "Trim Prefix Of Some Elements", p: "paramMixed", want: []string{"foo", "org.example.bar"}},
+		{name: "Trim Prefix Of String", p: "paramString", want: []string{"foo"}},
+		{name: "Trim Prefix Of Missing Parameter", p: "paramMissing", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetStringArrayTrimPrefix(tt.p, "com.example."); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetStringArrayTrimPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_LookupStringAt(t *testing.T) {
+	c := &Config{"paramArray": []string{"foo", ""}, "paramFloatArray": []float64{42.1}}
+	tests := []struct {
+		name   string
+		p      string
+		i      int
+		want   string
+		wantOk bool
+	}{
+		{name: "Lookup In Range", p: "paramArray", i: 0, want: "foo", wantOk: true},
+		{name: "Lookup Empty Element", p: "paramArray", i: 1, want: "", wantOk: true},
+		{name: "Lookup Number Element", p: "paramFloatArray", i: 0, want: "42.1", wantOk: true},
+		{name: "Lookup Out Of Range", p: "paramArray", i: 2, want: "", wantOk: false},
+		{name: "Lookup Negative Index", p: "paramArray", i: -1, want: "", wantOk: false},
+		{name: "Lookup Missing Parameter", p: "paramMissing", i: 0, want: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.LookupStringAt(tt.p, tt.i)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Config.LookupStringAt() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringArraySlice(t *testing.T) {
+	c := &Config{"paramArray": []string{"foo", "bar", "baz", "qux"}}
+	tests := []struct {
+		name       string
+		p          string
+		start, end int
+		want       []string
+	}{
+		{name: "Get In Range", p: "paramArray", start: 1, end: 3, want: []string{"bar", "baz"}},
+		{name: "Get Partially Out Of Range", p: "paramArray", start: -1, end: 2, want: []string{"foo", "bar"}},
+		{name: "Get Partially Out Of Range End", p: "paramArray", start: 2, end: 10, want: []string{"baz", "qux"}},
+		{name: "Get Fully Out Of Range", p: "paramArray", start: 5, end: 10, want: []string{}},
+		{name: "Get Invalid Range", p: "paramArray", start: 3, end: 1, want: []string{}},
+		{name: "Get Missing Parameter", p: "paramMissing", start: 0, end: 2, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetStringArraySlice(tt.p, tt.start, tt.end); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetStringArraySlice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GroupStringArray(t *testing.T) {
+	c := &Config{"paramArray": []string{"foo", "bar", "fizz", "baz"}}
+	tests := []struct {
+		name  string
+		p     string
+		keyFn func(string) string
+		want  map[string][]string
+	}{
+		{
+			name:  "Group By First Letter",
+			p:     "paramArray",
+			keyFn: func(s string) string { return s[:1] },
+			want:  map[string][]string{"f": {"foo", "fizz"}, "b": {"bar", "baz"}},
+		}, {
+			name:  "Group By Constant Key",
+			p:     "paramArray",
+			keyFn: func(s string) string { return "all" },
+			want:  map[string][]string{"all": {"foo", "bar", "fizz", "baz"}},
+		}, {
+			name:  "Group Missing Parameter",
+			p:     "paramMissing",
+			keyFn: func(s string) string { return s },
+			want:  map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GroupStringArray(tt.p, tt.keyFn); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GroupStringArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_CountDistinct(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name string
+		c    *Config
+		args args
+		want int
+	}{
+		{
+			name: "Count With Duplicates",
+			args: args{p: "paramStringArray"},
+			c:    &Config{"paramStringArray": []string{"foo", "bar", "foo", "baz", "bar"}},
+			want: 3,
+		}, {
+			name: "Count All Unique",
+			args: args{p: "paramStringArray"},
+			c:    &Config{"paramStringArray": []string{"foo", "bar", "baz"}},
+			want: 3,
+		}, {
+			name: "Count Float Array",
+			args: args{p: "paramFloatArray"},
+			c:    &Config{"paramFloatArray": []float64{1, 1.0, 2}},
+			want: 2,
+		}, {
+			name: "Count Missing Parameter",
+			args: args{p: "paramStringArray"},
+			c:    &Config{},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.CountDistinct(tt.args.p); got != tt.want {
+				t.Errorf("Config.CountDistinct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_FloatArrayAggregates(t *testing.T) {
+	c := &Config{
+		"paramFloatArray": []float64{4.5, -1, 10, 2.5},
+		"paramEmptyArray": []float64{},
+	}
+	tests := []struct {
+		name    string
+		p       string
+		wantSum float64
+		wantMax float64
+		wantMin float64
+	}{
+		{name: "Aggregate Float Array", p: "paramFloatArray", wantSum: 16, wantMax: 10, wantMin: -1},
+		{name: "Aggregate Empty Array", p: "paramEmptyArray"},
+		{name: "Aggregate Missing Parameter", p: "paramMissing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.SumFloatArray(tt.p); got != tt.wantSum {
+				t.Errorf("Config.SumFloatArray() = %v, want %v", got, tt.wantSum)
+			}
+			if got := c.MaxFloatArray(tt.p); got != tt.wantMax {
+				t.Errorf("Config.MaxFloatArray() = %v, want %v", got, tt.wantMax)
+			}
+			if got := c.MinFloatArray(tt.p); got != tt.wantMin {
+				t.Errorf("Config.MinFloatArray() = %v, want %v", got, tt.wantMin)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringArrayValidated(t *testing.T) {
+	c := &Config{
+		"paramHosts":      []string{"foo.com", "bar com", "baz.com"},
+		"paramValidHosts": []string{"foo.com", "baz.com"},
+	}
+	valid := func(host string) error {
+		if strings.Contains(host, " ") {
+			return errors.New("invalid hostname")
+		}
+		return nil
+	}
+	tests := []struct {
+		name    string
+		p       string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "Get Valid Elements",
+			p:    "paramValidHosts",
+			want: []string{"foo.com", "baz.com"},
+		}, {
+			name:    "Get Invalid Element",
+			p:       "paramHosts",
+			wantErr: `Parameter paramHosts is invalid: element 1 ("bar com"): invalid hostname`,
+		}, {
+			name: "Get Missing Parameter",
+			p:    "paramMissing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.GetStringArrayValidated(tt.p, valid)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Config.GetStringArrayValidated() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetStringArrayValidated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetEnumArray(t *testing.T) {
+	c := &Config{
+		"paramLevels":        []string{"error", "WARN", "Info"},
+		"paramInvalidLevels": []string{"error", "verbose", "info", "trace"},
+	}
+	allowed := []string{"error", "warn", "info", "debug"}
+	tests := []struct {
+		name    string
+		p       string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "Get Valid Enum Values",
+			p:    "paramLevels",
+			want: []string{"error", "warn", "info"},
+		}, {
+			name:    "Get Values Out Of Enum",
+			p:       "paramInvalidLevels",
+			wantErr: `Parameter paramInvalidLevels is invalid: element 1 ("verbose") is not one of error, warn, info, debug; element 3 ("trace") is not one of error, warn, info, debug`,
+		}, {
+			name: "Get Missing Parameter",
+			p:    "paramMissing",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.GetEnumArray(tt.p, allowed)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Config.GetEnumArray() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetEnumArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetIPArray(t *testing.T) {
+	c := &Config{
+		"paramIPs":        []string{"192.168.0.1", "::1", "2001:db8::68"},
+		"paramInvalidIPs": []string{"192.168.0.1", "::1", "192.168.0.256"},
+	}
+	tests := []struct {
+		name    string
+		p       string
+		want    []net.IP
+		wantErr string
+	}{
+		{
+			name: "Get Valid IPs",
+			p:    "paramIPs",
+			want: []net.IP{net.ParseIP("192.168.0.1"), net.IPv6loopback, net.ParseIP("2001:db8::68")},
+		}, {
+			name:    "Get Invalid IP",
+			p:       "paramInvalidIPs",
+			wantErr: `Parameter paramInvalidIPs is invalid: element 2 ("192.168.0.256") is not an IP address`,
+		}, {
+			name: "Get Missing Parameter",
+			p:    "paramMissing",
+			want: []net.IP{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.GetIPArray(tt.p)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Config.GetIPArray() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetIPArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringArrayJSON(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantA []string
+	}{
+		{
+			name:  "Get String Array With Special Characters",
+			args:  args{p: "paramStringArray"},
+			c:     &Config{"paramStringArray": []string{"foo,bar", `say "hi"`, "back\\slash\n"}},
+			wantA: []string{`"foo,bar"`, `"say \"hi\""`, `"back\\slash\n"`},
+		}, {
+			name:  "Get Float Array",
+			args:  args{p: "paramFloatArray"},
+			c:     &Config{"paramFloatArray": []float64{42, 0.1}},
+			wantA: []string{"42", "0.1"},
+		}, {
+			name:  "Get Bool Array",
+			args:  args{p: "paramBoolArray"},
+			c:     &Config{"paramBoolArray": []bool{true, false}},
+			wantA: []string{"true", "false"},
+		}, {
+			name:  "Get Scalar",
+			args:  args{p: "paramString"},
+			c:     &Config{"paramString": "foo"},
+			wantA: []string{`"foo"`},
+		}, {
+			name:  "Get Missing Parameter",
+			args:  args{p: "paramStringArray"},
+			c:     &Config{},
+			wantA: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotA := tt.c.GetStringArrayJSON(tt.args.p); !reflect.DeepEqual(gotA, tt.wantA) {
+				t.Errorf("Config.GetStringArrayJSON() = %v, want %v", gotA, tt.wantA)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringArrayChunks(t *testing.T) {
+	c := &Config{
+		"paramArray": []string{"a", "b", "c", "d", "e", "f"},
+		"paramOdd":   []string{"a", "b", "c", "d", "e"},
+	}
+	type args struct {
+		p    string
+		size int
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][]string
+	}{
+		{
+			name: "Even Chunks",
+			args: args{p: "paramArray", size: 2},
+			want: [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}},
+		}, {
+			name: "Uneven Chunks",
+			args: args{p: "paramOdd", size: 2},
+			want: [][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+		}, {
+			name: "Chunk Larger Than Array",
+			args: args{p: "paramOdd", size: 10},
+			want: [][]string{{"a", "b", "c", "d", "e"}},
+		}, {
+			name: "Zero Size",
+			args: args{p: "paramOdd", size: 0},
+			want: [][]string{{"a", "b", "c", "d", "e"}},
+		}, {
+			name: "Negative Size",
+			args: args{p: "paramOdd", size: -1},
+			want: [][]string{{"a", "b", "c", "d", "e"}},
+		}, {
+			name: "Missing Parameter",
+			args: args{p: "paramMissing", size: 2},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetStringArrayChunks(tt.args.p, tt.args.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetStringArrayChunks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetStringMap(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	c, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	nested := &Config{"paramObj": map[string]interface{}{"paramString": "foo"}}
+	tests := []struct {
+		name string
+		c    *Config
+		p    string
+		want map[string]interface{}
+	}{
+		{
+			name: "Get Object",
+			c:    &c,
+			p:    "paramObj",
+			want: map[string]interface{}{
+				"paramIntArray":      []float64{0, 1, 2},
+				"paramFloatArray":    []float64{0.1, 1.1, 2.1},
+				"paramStringArray":   []string{"foo", "bar", "baz"},
+				"paramBoolArray":     []bool{true, false, true},
+				"paramDurationArray": []string{"10h10m", "10h20m", "10h30m"},
+			},
+		}, {
+			name: "Get Object With Nested Objects",
+			c:    &Config{"paramObj.paramString": "foo", "paramObj.paramSub.paramBool": true},
+			p:    "paramObj",
+			want: map[string]interface{}{
+				"paramString": "foo",
+				"paramSub":    map[string]interface{}{"paramBool": true},
+			},
+		}, {
+			name: "Get Object Without Flatten",
+			c:    nested,
+			p:    "paramObj",
+			want: map[string]interface{}{"paramString": "foo"},
+		}, {
+			name: "Get Scalar",
+			c:    &c,
+			p:    "paramString",
+			want: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.GetStringMap(tt.p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetStringMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetKVMap(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name  string
+		c     *Config
+		args  args
+		wantM map[string]string
+	}{
+		{
+			name:  "Get Well-Formed Pairs",
+			args:  args{p: "paramArray"},
+			c:     &Config{"paramArray": []string{"k1=v1", "k2=v2=v3", "k3="}},
+			wantM: map[string]string{"k1": "v1", "k2": "v2=v3", "k3": ""},
+		}, {
+			name:  "Get Malformed Pair",
+			args:  args{p: "paramArray"},
+			c:     &Config{"paramArray": []string{"k1=v1", "k2"}},
+			wantM: map[string]string{"k1": "v1"},
+		}, {
+			name:  "Get Missing Parameter",
+			args:  args{p: "paramArray"},
+			c:     &Config{},
+			wantM: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotM := tt.c.GetKVMap(tt.args.p); !reflect.DeepEqual(gotM, tt.wantM) {
+				t.Errorf("Config.GetKVMap() = %v, want %v", gotM, tt.wantM)
+			}
+		})
+	}
+}
+
+func TestConfig_GetGlobMatches(t *testing.T) {
+	err := os.Mkdir("conf.d", 0755)
+	if err != nil {
+		t.Fatal("Could not generate test directory conf.d")
+	}
+	defer os.RemoveAll("conf.d")
+	for _, file := range []string{"b.yaml", "a.yaml", "c.json"} {
+		err := ioutil.WriteFile(filepath.Join("conf.d", file), []byte("param: foo"), 0644)
+		if err != nil {
+			t.Fatal("Could not generate test file " + file)
+		}
+	}
+
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		name    string
+		c       *Config
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Get Several Matches",
+			args: args{p: "include"},
+			c:    &Config{"include": "conf.d/*.yaml"},
+			want: []string{filepath.Join("conf.d", "a.yaml"), filepath.Join("conf.d", "b.yaml")},
+		}, {
+			name: "Get No Match",
+			args: args{p: "include"},
+			c:    &Config{"include": "conf.d/*.toml"},
+			want: nil,
+		}, {
+			name:    "Get Malformed Pattern",
+			args:    args{p: "include"},
+			c:       &Config{"include": "conf.d/[*.yaml"},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.c.GetGlobMatches(tt.args.p, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.GetGlobMatches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetGlobMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Get Matches Relative To Configuration File", func(t *testing.T) {
+		err := os.MkdirAll(filepath.Join("conf-glob", "conf.d"), 0755)
+		if err != nil {
+			t.Fatal("Could not generate test directory conf-glob")
+		}
+		defer os.RemoveAll("conf-glob")
+		for _, file := range []string{"conf.yaml", filepath.Join("conf.d", "a.yaml"), filepath.Join("conf.d", "b.yaml")} {
+			err := ioutil.WriteFile(filepath.Join("conf-glob", file), []byte("include: conf.d/*.yaml"), 0644)
+			if err != nil {
+				t.Fatal("Could not generate test file " + file)
+			}
+		}
+		c, err := Load(filepath.Join("conf-glob", "conf.yaml"))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		dir, _ := filepath.Abs("conf-glob")
+		want := []string{filepath.Join(dir, "conf.d", "a.yaml"), filepath.Join(dir, "conf.d", "b.yaml")}
+		if got, err := c.GetGlobMatches("include", dir); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Config.GetGlobMatches() = %v, %v, want %v", got, err, want)
+		}
+	})
+}
+
+func TestConfig_GetScalarOrArray(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
 		name  string
 		c     *Config
 		args  args
-		wantB bool
+		wantA []interface{}
 	}{
 		{
-			name:  "Get Boolean",
-			args:  args{p: "paramBool"},
-			c:     &Config{"paramBool": true},
-			wantB: true,
+			name:  "Get Scalar",
+			args:  args{p: "param.host"},
+			c:     &Config{"param.host": "foo"},
+			wantA: []interface{}{"foo"},
 		}, {
-			name:  "Get Boolean From Float",
-			args:  args{p: "paramFloat"},
-			c:     &Config{"paramFloat": 42.1},
-			wantB: true,
+			name:  "Get Number Scalar",
+			args:  args{p: "param.port"},
+			c:     &Config{"param.port": 42.0},
+			wantA: []interface{}{42.0},
 		}, {
-			name:  "Get Bool From Bool Array",
-			args:  args{p: "paramBoolArray"},
-			c:     &Config{"paramBoolArray": []bool{true, false, true}},
-			wantB: true,
+			name:  "Get String Array",
+			args:  args{p: "param.host"},
+			c:     &Config{"param.host": []string{"foo", "bar"}, "param.host.0": "foo", "param.host.1": "bar"},
+			wantA: []interface{}{"foo", "bar"},
 		}, {
-			name:  "Get Bool From Float Array",
-			args:  args{p: "paramFloatArray"},
-			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
-			wantB: true,
+			name:  "Get Bool Array",
+			args:  args{p: "param.enabled"},
+			c:     &Config{"param.enabled": []bool{true, false}},
+			wantA: []interface{}{true, false},
+		}, {
+			name:  "Get Missing Parameter",
+			args:  args{p: "param"},
+			c:     &Config{"param.host": "foo"},
+			wantA: nil,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotB := tt.c.GetBool(tt.args.p); gotB != tt.wantB {
-				t.Errorf("Config.GetBool() = %v, want %v", gotB, tt.wantB)
+			if gotA := tt.c.GetScalarOrArray(tt.args.p); !reflect.DeepEqual(gotA, tt.wantA) {
+				t.Errorf("Config.GetScalarOrArray() = %v, want %v", gotA, tt.wantA)
 			}
 		})
 	}
 }
 
-func TestConfig_GetStringArray(t *testing.T) {
+func TestConfig_GetInterfaceArray(t *testing.T) {
+	c := &Config{
+		"paramStringArray": []string{"foo", "bar"},
+		"paramFloatArray":  []float64{42.1, 43},
+		"paramBoolArray":   []bool{true, false},
+		"paramNestedArray": []interface{}{"foo", map[string]interface{}{"paramBool": true}},
+		"paramString":      "foo",
+	}
+	tests := []struct {
+		name string
+		p    string
+		want []interface{}
+	}{
+		{name: "Get String Array", p: "paramStringArray", want: []interface{}{"foo", "bar"}},
+		{name: "Get Float Array", p: "paramFloatArray", want: []interface{}{42.1, 43.0}},
+		{name: "Get Bool Array", p: "paramBoolArray", want: []interface{}{true, false}},
+		{name: "Get Nested Array", p: "paramNestedArray", want: []interface{}{"foo", map[string]interface{}{"paramBool": true}}},
+		{name: "Get Scalar", p: "paramString", want: []interface{}{"foo"}},
+		{name: "Get Missing", p: "paramMissing", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetInterfaceArray(tt.p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.GetInterfaceArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_ArrayContains(t *testing.T) {
 	type args struct {
-		p string
+		p     string
+		value string
 	}
 	tests := []struct {
-		name  string
-		c     *Config
-		args  args
-		wantA []string
+		name string
+		c    *Config
+		args args
+		want bool
 	}{
 		{
-			name:  "Get String Array",
-			args:  args{p: "paramStringArray"},
-			c:     &Config{"paramStringArray": []string{"foo", "bar", "baz"}},
-			wantA: []string{"foo", "bar", "baz"},
+			name: "String Array Contains Value",
+			args: args{p: "paramStringArray", value: "bar"},
+			c:    &Config{"paramStringArray": []string{"foo", "bar", "baz"}},
+			want: true,
 		}, {
-			name:  "Get String Array From Float Array",
-			args:  args{p: "paramFloatArray"},
-			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
-			wantA: []string{"0.1", "1.1", "2.1"},
+			name: "String Array Does Not Contain Value",
+			args: args{p: "paramStringArray", value: "qux"},
+			c:    &Config{"paramStringArray": []string{"foo", "bar", "baz"}},
+			want: false,
 		}, {
-			name:  "Get String Array From Bool Array",
-			args:  args{p: "paramBoolArray"},
-			c:     &Config{"paramBoolArray": []bool{true, false, true}},
-			wantA: []string{"true", "false", "true"},
+			name: "Float Array Contains Value",
+			args: args{p: "paramFloatArray", value: "1.1"},
+			c:    &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
+			want: true,
 		}, {
-			name:  "Get String Array From String",
-			args:  args{p: "paramString"},
-			c:     &Config{"paramString": "foo"},
-			wantA: []string{"foo"},
+			name: "Non-Array Parameter",
+			args: args{p: "paramString", value: "foo"},
+			c:    &Config{"paramString": "foo"},
+			want: false,
 		}, {
-			name:  "Get String Array From Float",
-			args:  args{p: "paramFloat"},
-			c:     &Config{"paramFloat": 0.1},
-			wantA: []string{"0.1"},
+			name: "Missing Parameter",
+			args: args{p: "paramStringArray", value: "foo"},
+			c:    &Config{},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.ArrayContains(tt.args.p, tt.args.value); got != tt.want {
+				t.Errorf("Config.ArrayContains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Alias(t *testing.T) {
+	type args struct {
+		oldKey string
+		newKey string
+	}
+	tests := []struct {
+		name string
+		c    *Config
+		args args
+		want Config
+	}{
+		{
+			name: "Alias With Only New Key Set",
+			args: args{oldKey: "paramOld", newKey: "paramNew"},
+			c:    &Config{"paramNew": "foo"},
+			want: Config{"paramNew": "foo", "paramOld": "foo"},
 		}, {
-			name:  "Get String Array From Bool",
-			args:  args{p: "paramBool"},
-			c:     &Config{"paramBool": true},
-			wantA: []string{"true"},
+			name: "Alias With Both Keys Set",
+			args: args{oldKey: "paramOld", newKey: "paramNew"},
+			c:    &Config{"paramNew": "foo", "paramOld": "bar"},
+			want: Config{"paramNew": "foo", "paramOld": "bar"},
+		}, {
+			name: "Alias Object",
+			args: args{oldKey: "paramOld", newKey: "paramObj.paramNew"},
+			c: &Config{
+				"paramObj.paramNew.paramString": "foo",
+				"paramObj.paramNew.paramArray":  []float64{1, 2}, "paramObj.paramNew.paramArray.0": 1.0, "paramObj.paramNew.paramArray.1": 2.0,
+				"paramObj.paramNewer": "bar",
+			},
+			want: Config{
+				"paramObj.paramNew.paramString": "foo",
+				"paramObj.paramNew.paramArray":  []float64{1, 2}, "paramObj.paramNew.paramArray.0": 1.0, "paramObj.paramNew.paramArray.1": 2.0,
+				"paramObj.paramNewer":  "bar",
+				"paramOld.paramString": "foo",
+				"paramOld.paramArray":  []float64{1, 2}, "paramOld.paramArray.0": 1.0, "paramOld.paramArray.1": 2.0,
+			},
+		}, {
+			name: "Alias Missing Key",
+			args: args{oldKey: "paramOld", newKey: "paramNew"},
+			c:    &Config{"paramString": "foo"},
+			want: Config{"paramString": "foo"},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotA := tt.c.GetStringArray(tt.args.p); !reflect.DeepEqual(gotA, tt.wantA) {
-				t.Errorf("Config.GetStringArray() = %v, want %v", gotA, tt.wantA)
+			tt.c.Alias(tt.args.oldKey, tt.args.newKey)
+			if !reflect.DeepEqual(*tt.c, tt.want) {
+				t.Errorf("Config.Alias() = %v, want %v", *tt.c, tt.want)
+			}
+		})
+	}
+
+	t.Run("Alias With Delimiter", func(t *testing.T) {
+		c, err := LoadFromBytes([]byte(`{"new": {"x": 1}}`), ".json", WithDelimiter("/"))
+		if err != nil {
+			t.Fatalf("LoadFromBytes() error = %v", err)
+		}
+		c.Alias("old", "new", WithDelimiter("/"))
+		if got := c.GetInt("old/x"); got != 1 {
+			t.Errorf("Config.Alias() old/x = %v, want 1", got)
+		}
+	})
+}
+
+func TestConfig_Unmarshal(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	type obj struct {
+		IntArray         []int     `confloader:"paramIntArray"`
+		Floats           []float32 `confloader:"paramFloatArray"`
+		ParamStringArray []string
+		ParamBoolArray   []bool
+		Durations        []time.Duration `confloader:"paramDurationArray"`
+	}
+	type conf struct {
+		String     string `confloader:"paramString"`
+		Int        int    `confloader:"paramInt"`
+		NestedInt  uint8  `confloader:"paramObj.paramIntArray.2"`
+		Paramfloat float64
+		ParamBool  bool
+		Duration   time.Duration `confloader:"paramDuration"`
+		ParamObj   *obj
+		Skipped    string `confloader:"-"`
+		Missing    string
+	}
+	want := conf{
+		String: "foo", Int: 42, NestedInt: 2, Paramfloat: 42.1, ParamBool: true,
+		Duration: 10*time.Hour + 10*time.Minute,
+		ParamObj: &obj{
+			IntArray:         []int{0, 1, 2},
+			Floats:           []float32{0.1, 1.1, 2.1},
+			ParamStringArray: []string{"foo", "bar", "baz"},
+			ParamBoolArray:   []bool{true, false, true},
+			Durations:        []time.Duration{10*time.Hour + 10*time.Minute, 10*time.Hour + 20*time.Minute, 10*time.Hour + 30*time.Minute},
+		},
+		Skipped: "unchanged",
+		Missing: "unchanged",
+	}
+	for _, filename := range []string{"complex-conf.json", "complex-conf.yaml"} {
+		t.Run(filename, func(t *testing.T) {
+			c, err := Load(filename)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			got := conf{Skipped: "unchanged", Missing: "unchanged"}
+			if err := c.Unmarshal(&got); err != nil {
+				t.Fatalf("Config.Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Config.Unmarshal() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	errTests := []struct {
+		name string
+		c    *Config
+		v    interface{}
+	}{
+		{
+			name: "Unmarshal String Into Int",
+			c:    &Config{"paramInt": "abc"},
+			v: &struct {
+				ParamInt int
+			}{},
+		}, {
+			name: "Unmarshal Overflowing Int",
+			c:    &Config{"paramInt": 300.0},
+			v: &struct {
+				ParamInt int8
+			}{},
+		}, {
+			name: "Unmarshal Invalid Duration Element",
+			c:    &Config{"paramArray": []string{"10s", "foo"}},
+			v: &struct {
+				ParamArray []time.Duration
+			}{},
+		}, {
+			name: "Unmarshal Unsupported Type",
+			c:    &Config{"paramChan": "foo"},
+			v: &struct {
+				ParamChan chan int
+			}{},
+		}, {
+			name: "Unmarshal Non-Pointer",
+			c:    &Config{"paramString": "foo"},
+			v: struct {
+				ParamString string
+			}{},
+		},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.Unmarshal(tt.v); err == nil {
+				t.Errorf("Config.Unmarshal() error = %v, wantErr true", err)
+			}
+		})
+	}
+
+	t.Run("Unmarshal With Delimiter", func(t *testing.T) {
+		c, err := LoadFromBytes([]byte(`{"new": {"x": 1, "arr": [1, 2]}, "feature.v2": true}`), ".json", WithDelimiter("/"))
+		if err != nil {
+			t.Fatalf("LoadFromBytes() error = %v", err)
+		}
+		var got struct {
+			New struct {
+				X   int
+				Arr []int
+			}
+			Feature bool `confloader:"feature.v2"`
+		}
+		if err := c.Unmarshal(&got, WithDelimiter("/")); err != nil {
+			t.Fatalf("Config.Unmarshal() error = %v", err)
+		}
+		if got.New.X != 1 || !reflect.DeepEqual(got.New.Arr, []int{1, 2}) || !got.Feature {
+			t.Errorf("Config.Unmarshal() = %+v, want {New:{X:1 Arr:[1 2]} Feature:true}", got)
+		}
+	})
+}
+
+func TestConfig_Filter(t *testing.T) {
+	c := &Config{
+		"paramString": "foo", "paramInt": 42.0,
+		"paramObj.paramString": "bar", "paramObj.paramBool": true,
+		"paramObjOther": "baz",
+	}
+	tests := []struct {
+		name string
+		pred func(key string, value interface{}) bool
+		want Config
+	}{
+		{
+			name: "Filter String Values",
+			pred: func(key string, value interface{}) bool {
+				_, ok := value.(string)
+				return ok
+			},
+			want: Config{"paramString": "foo", "paramObj.paramString": "bar", "paramObjOther": "baz"},
+		}, {
+			name: "Filter Keys Under Prefix",
+			pred: func(key string, value interface{}) bool {
+				return strings.HasPrefix(key, "paramObj.")
+			},
+			want: Config{"paramObj.paramString": "bar", "paramObj.paramBool": true},
+		}, {
+			name: "Filter Nothing",
+			pred: func(key string, value interface{}) bool {
+				return false
+			},
+			want: Config{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Filter(tt.pred); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Keys(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	want := []string{
+		"paramBool",
+		"paramDuration",
+		"paramFloat",
+		"paramInt",
+		"paramObj.paramBoolArray", "paramObj.paramBoolArray.0", "paramObj.paramBoolArray.1", "paramObj.paramBoolArray.2",
+		"paramObj.paramDurationArray", "paramObj.paramDurationArray.0", "paramObj.paramDurationArray.1", "paramObj.paramDurationArray.2",
+		"paramObj.paramFloatArray", "paramObj.paramFloatArray.0", "paramObj.paramFloatArray.1", "paramObj.paramFloatArray.2",
+		"paramObj.paramIntArray", "paramObj.paramIntArray.0", "paramObj.paramIntArray.1", "paramObj.paramIntArray.2",
+		"paramObj.paramStringArray", "paramObj.paramStringArray.0", "paramObj.paramStringArray.1", "paramObj.paramStringArray.2",
+		"paramString",
+	}
+	c, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := c.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Config.Keys() = %v, want %v", got, want)
+	}
+}
+
+func TestConfig_KeysByType(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	want := map[string][]string{
+		"string": {
+			"paramDuration",
+			"paramObj.paramDurationArray.0", "paramObj.paramDurationArray.1", "paramObj.paramDurationArray.2",
+			"paramObj.paramStringArray.0", "paramObj.paramStringArray.1", "paramObj.paramStringArray.2",
+			"paramString",
+		},
+		"float64": {
+			"paramFloat", "paramInt",
+			"paramObj.paramFloatArray.0", "paramObj.paramFloatArray.1", "paramObj.paramFloatArray.2",
+			"paramObj.paramIntArray.0", "paramObj.paramIntArray.1", "paramObj.paramIntArray.2",
+		},
+		"bool": {
+			"paramBool",
+			"paramObj.paramBoolArray.0", "paramObj.paramBoolArray.1", "paramObj.paramBoolArray.2",
+		},
+		"[]string":  {"paramObj.paramDurationArray", "paramObj.paramStringArray"},
+		"[]float64": {"paramObj.paramFloatArray", "paramObj.paramIntArray"},
+		"[]bool":    {"paramObj.paramBoolArray"},
+	}
+	c, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := c.KeysByType(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Config.KeysByType() = %v, want %v", got, want)
+	}
+}
+
+func TestConfig_WalkLeaves(t *testing.T) {
+	c := &Config{
+		"paramString": "foo", "paramObj.paramInt": 42.0,
+		"paramArray": []string{"foo", "bar"}, "paramArray.0": "foo", "paramArray.1": "bar",
+		"paramBoolArray": []bool{true}, "paramBoolArray.0": true,
+	}
+	want := []KeyValue{
+		{Key: "paramArray.0", Value: "foo"},
+		{Key: "paramArray.1", Value: "bar"},
+		{Key: "paramBoolArray.0", Value: true},
+		{Key: "paramObj.paramInt", Value: 42.0},
+		{Key: "paramString", Value: "foo"},
+	}
+	var got []KeyValue
+	c.WalkLeaves(func(key string, value interface{}) {
+		got = append(got, KeyValue{Key: key, Value: value})
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Config.WalkLeaves() visited %v, want %v", got, want)
+	}
+}
+
+func TestConfig_Clone(t *testing.T) {
+	c := Config{
+		"paramString":      "foo",
+		"paramStringArray": []string{"foo", "bar"}, "paramStringArray.0": "foo", "paramStringArray.1": "bar",
+		"paramFloatArray": []float64{1, 2}, "paramFloatArray.0": 1.0, "paramFloatArray.1": 2.0,
+		"paramBoolArray": []bool{true}, "paramBoolArray.0": true,
+	}
+	want := Config{
+		"paramString":      "foo",
+		"paramStringArray": []string{"foo", "bar"}, "paramStringArray.0": "foo", "paramStringArray.1": "bar",
+		"paramFloatArray": []float64{1, 2}, "paramFloatArray.0": 1.0, "paramFloatArray.1": 2.0,
+		"paramBoolArray": []bool{true}, "paramBoolArray.0": true,
+	}
+
+	clone := c.Clone()
+	if !reflect.DeepEqual(clone, want) {
+		t.Errorf("Config.Clone() = %v, want %v", clone, want)
+	}
+
+	clone["paramStringArray"].([]string)[0] = "baz"
+	clone["paramFloatArray"].([]float64)[0] = 3
+	clone["paramBoolArray"].([]bool)[0] = false
+	clone.Set("paramString", "bar")
+	clone.Set("paramNew", 42)
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Config.Clone() shares values with original: %v, want %v", c, want)
+	}
+
+	nested, err := LoadFromBytes([]byte(`{"a": {"b": 1}, "list": [{"c": 2}]}`), ".json", WithoutFlatten())
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+	nestedClone := nested.Clone()
+	nestedClone["a"].(map[string]interface{})["b"] = 3.0
+	nestedClone["list"].([]interface{})[0].(map[string]interface{})["c"] = 4.0
+	if want := (Config{"a": map[string]interface{}{"b": 1.0}, "list": []interface{}{map[string]interface{}{"c": 2.0}}}); !reflect.DeepEqual(nested, want) {
+		t.Errorf("Config.Clone() shares nested objects with original: %v, want %v", nested, want)
+	}
+}
+
+func TestConfig_SnapshotRestore(t *testing.T) {
+	c := &Config{
+		"paramString": "foo", "paramInt": 42.0,
+		"paramArray": []string{"foo", "bar"}, "paramArray.0": "foo", "paramArray.1": "bar",
+	}
+	want := Config{
+		"paramString": "foo", "paramInt": 42.0,
+		"paramArray": []string{"foo", "bar"}, "paramArray.0": "foo", "paramArray.1": "bar",
+	}
+
+	snap := c.Snapshot()
+	if !reflect.DeepEqual(snap, want) {
+		t.Errorf("Config.Snapshot() = %v, want %v", snap, want)
+	}
+
+	(*c)["paramString"] = "baz"
+	(*c)["paramNew"] = true
+	delete(*c, "paramInt")
+	(*c)["paramArray"].([]string)[0] = "baz"
+	if !reflect.DeepEqual(snap, want) {
+		t.Errorf("Config.Snapshot() = %v after mutation, want %v", snap, want)
+	}
+
+	c.Restore(snap)
+	if !reflect.DeepEqual(*c, want) {
+		t.Errorf("Config.Restore() = %v, want %v", *c, want)
+	}
+
+	(*c)["paramArray"].([]string)[0] = "baz"
+	if !reflect.DeepEqual(snap, want) {
+		t.Errorf("Config.Restore() shares arrays with snapshot: %v, want %v", snap, want)
+	}
+
+	nested := Config{"a": map[string]interface{}{"b": 1.0}}
+	nestedSnap := nested.Snapshot()
+	nested["a"].(map[string]interface{})["b"] = 2.0
+	nested.Restore(nestedSnap)
+	nested["a"].(map[string]interface{})["b"] = 3.0
+	if want := (Config{"a": map[string]interface{}{"b": 1.0}}); !reflect.DeepEqual(nestedSnap, want) {
+		t.Errorf("Config.Snapshot() shares nested objects: %v, want %v", nestedSnap, want)
+	}
+}
+
+func TestConfig_Sub(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	c, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		prefix string
+		want   Config
+	}{
+		{
+			name:   "Sub Object",
+			prefix: "paramObj",
+			want: Config{
+				"paramIntArray": []float64{0, 1, 2}, "paramIntArray.0": 0.0, "paramIntArray.1": 1.0, "paramIntArray.2": 2.0,
+				"paramFloatArray": []float64{0.1, 1.1, 2.1}, "paramFloatArray.0": 0.1, "paramFloatArray.1": 1.1, "paramFloatArray.2": 2.1,
+				"paramStringArray": []string{"foo", "bar", "baz"}, "paramStringArray.0": "foo", "paramStringArray.1": "bar", "paramStringArray.2": "baz",
+				"paramBoolArray": []bool{true, false, true}, "paramBoolArray.0": true, "paramBoolArray.1": false, "paramBoolArray.2": true,
+				"paramDurationArray": []string{"10h10m", "10h20m", "10h30m"}, "paramDurationArray.0": "10h10m", "paramDurationArray.1": "10h20m", "paramDurationArray.2": "10h30m",
+			},
+		}, {
+			name:   "Sub Array",
+			prefix: "paramObj.paramStringArray",
+			want:   Config{"0": "foo", "1": "bar", "2": "baz"},
+		}, {
+			name:   "Sub Scalar",
+			prefix: "paramString",
+			want:   Config{},
+		}, {
+			name:   "Sub Missing Prefix",
+			prefix: "paramMissing",
+			want:   Config{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Sub(tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.Sub() = %v, want %v", got, tt.want)
 			}
 		})
 	}
-}
 
-func TestConfig_GetFloatArray(t *testing.T) {
-	type args struct {
-		p string
+	t.Run("Sub Keeps Sensitivity", func(t *testing.T) {
+		c := Config{"db.password": Sensitive{Value: "secret"}, "db.host": "localhost"}
+		sub := c.Sub("db")
+		if got, want := sub.String(), "host=localhost\npassword="+redacted; got != want {
+			t.Errorf("Config.Sub().String() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestLoad_NonStringYAMLKeys(t *testing.T) {
+	confPortsYAML := []byte(`
+ports:
+  8080: backend
+  443:
+    name: https
+flags:
+  true: on
+  false: off
+1.5: float
+`)
+	err := ioutil.WriteFile("conf-ports.yaml", confPortsYAML, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-ports.yaml")
+	}
+	defer os.Remove("conf-ports.yaml")
+
+	want := Config{
+		"ports.8080":     "backend",
+		"ports.443.name": "https",
+		"flags.true":     true,
+		"flags.false":    false,
+		"1.5":            "float",
+	}
+	got, err := Load("conf-ports.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+	got, _, err = LoadOrdered("conf-ports.yaml")
+	if err != nil {
+		t.Fatalf("LoadOrdered() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadOrdered() = %v, want %v", got, want)
+	}
+
+	nested, err := Load("conf-ports.yaml", WithoutFlatten())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if ports, ok := nested["ports"].(map[string]interface{}); !ok || ports["8080"] != "backend" {
+		t.Errorf("Load() ports = %v, want a map with key 8080", nested["ports"])
 	}
+}
+
+func TestLoad_MixedArrays(t *testing.T) {
 	tests := []struct {
-		name  string
-		c     *Config
-		args  args
-		wantA []float64
+		name   string
+		data   string
+		format string
+		want   Config
 	}{
 		{
-			name:  "Get Float Array",
-			args:  args{p: "paramFloatArray"},
-			c:     &Config{"paramFloatArray": []float64{0.1, 1.1, 2.1}},
-			wantA: []float64{0.1, 1.1, 2.1},
-		}, {
-			name:  "Get Float Array From Bool Array",
-			args:  args{p: "paramBoolArray"},
-			c:     &Config{"paramBoolArray": []bool{true, false, true}},
-			wantA: []float64{1.0, 0.0, 1.0},
-		}, {
-			name:  "Get Float Array From Float",
-			args:  args{p: "paramFloat"},
-			c:     &Config{"paramFloat": 42.1},
-			wantA: []float64{42.1},
+			name:   "Load Mixed Scalars",
+			data:   `{"paramArray": ["a", 1, true]}`,
+			format: ".json",
+			want: Config{
+				"paramArray":   []string{"a", "1", "true"},
+				"paramArray.0": "a", "paramArray.1": 1.0, "paramArray.2": true,
+			},
 		}, {
-			name:  "Get Float Array From Bool (True)",
-			args:  args{p: "paramBool"},
-			c:     &Config{"paramBool": true},
-			wantA: []float64{1.0},
+			name:   "Load Integers And Floats",
+			data:   "paramArray: [1, 2.5]\n",
+			format: ".yaml",
+			want: Config{
+				"paramArray":   []float64{1, 2.5},
+				"paramArray.0": 1.0, "paramArray.1": 2.5,
+			},
 		}, {
-			name:  "Get Float Array From Bool (False)",
-			args:  args{p: "paramBool"},
-			c:     &Config{"paramBool": false},
-			wantA: []float64{0.0},
+			name:   "Load Scalars And Objects",
+			data:   "paramArray:\n- a\n- param1: b\n- null\n",
+			format: ".yaml",
+			want: Config{
+				"paramArray.0": "a", "paramArray.1.param1": "b", "paramArray.2": nil,
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotA := tt.c.GetFloatArray(tt.args.p); !reflect.DeepEqual(gotA, tt.wantA) {
-				t.Errorf("Config.GetFloatArray() = %v, want %v", gotA, tt.wantA)
+			got, err := LoadFromBytes([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("LoadFromBytes() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFromBytes() = %v, want %v", got, tt.want)
 			}
 		})
 	}
+
+	c, _ := LoadFromBytes([]byte(`{"paramArray": ["a", 1, true]}`), ".json")
+	if got := c.GetStringArray("paramArray"); !reflect.DeepEqual(got, []string{"a", "1", "true"}) {
+		t.Errorf("Config.GetStringArray() = %v, want [a 1 true]", got)
+	}
+	if got := c.GetFloatArray("paramArray"); len(got) != 0 {
+		t.Errorf("Config.GetFloatArray() = %v, want []", got)
+	}
+	if got := c.GetBool("paramArray.2"); !got {
+		t.Errorf("Config.GetBool() = %v, want true", got)
+	}
+	if got := c.ArrayLen("paramArray"); got != 3 {
+		t.Errorf("Config.ArrayLen() = %v, want 3", got)
+	}
 }
 
-func TestConfig_GetIntArray(t *testing.T) {
-	type args struct {
-		p string
+func TestConfig_ArrayLen(t *testing.T) {
+	data := `{
+    "servers": [
+        {"host": "a"},
+        {"host": "b", "port": 8080, "tags": ["x", "y"]},
+        null,
+        {"name": {"first": "c"}},
+        "d"
+    ],
+    "ports": [80, 443],
+    "empty": [],
+    "scalar": "foo",
+    "obj": {"param1": "foo"}
+}`
+	c, err := LoadFromBytes([]byte(data), ".json")
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
 	}
 	tests := []struct {
-		name string
-		c    *Config
-		args args
-		want []int
+		p    string
+		want int
 	}{
-		{
-			name: "Get Int Array",
-			args: args{p: "paramFloatArray"},
-			c:    &Config{"paramFloatArray": []float64{0, 1, 2}},
-			want: []int{0, 1, 2},
-		}, {
-			name: "Get Int Array From Float Array",
-			args: args{p: "paramFloatArray"},
-			c:    &Config{"paramFloatArray": []float64{0.2, 1.4, 2.6}}, // floor casting
-			want: []int{0, 1, 2},
-		}, {
-			name: "Get Int Array From Bool Array",
-			args: args{p: "paramBoolArray"},
-			c:    &Config{"paramBoolArray": []bool{true, false, true}},
-			want: []int{1, 0, 1},
-		}, {
-			name: "Get Int Array From Float",
-			args: args{p: "paramFloat"},
-			c:    &Config{"paramFloat": 42.1},
-			want: []int{42},
-		}, {
-			name: "Get Int Array From Bool (True)",
-			args: args{p: "paramBool"},
-			c:    &Config{"paramBool": true},
-			want: []int{1},
-		}, {
-			name: "Get Int Array From Bool (False)",
-			args: args{p: "paramBool"},
-			c:    &Config{"paramBool": false},
-			want: []int{0},
-		},
+		{p: "servers", want: 5},
+		{p: "servers.1.tags", want: 2},
+		{p: "ports", want: 2},
+		{p: "empty", want: 0},
+		{p: "scalar", want: 0},
+		{p: "obj", want: 0},
+		{p: "missing", want: 0},
 	}
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.c.GetIntArray(tt.args.p); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Config.GetIntArray() = %v, want %v", got, tt.want)
+		t.Run(tt.p, func(t *testing.T) {
+			if got := c.ArrayLen(tt.p); got != tt.want {
+				t.Errorf("Config.ArrayLen() = %v, want %v", got, tt.want)
 			}
 		})
 	}
+
+	want := []Config{
+		{"host": "a"},
+		{"host": "b", "port": 8080.0, "tags": []string{"x", "y"}, "tags.0": "x", "tags.1": "y"},
+		{},
+		{"name.first": "c"},
+		{},
+	}
+	for i := 0; i < c.ArrayLen("servers"); i++ {
+		if got := c.Sub("servers." + strconv.Itoa(i)); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("Config.Sub() = %v, want %v", got, want[i])
+		}
+	}
+	if got := c.GetString("servers.4"); got != "d" {
+		t.Errorf("Config.GetString() = %v, want d", got)
+	}
 }
 
-func TestConfig_GetDurationArray(t *testing.T) {
-	type args struct {
-		p string
+func TestConfig_Set(t *testing.T) {
+	c := Config{
+		"paramString":         "foo",
+		"paramArray":          []string{"foo", "bar", "baz"},
+		"paramArray.0":        "foo",
+		"paramArray.1":        "bar",
+		"paramArray.2":        "baz",
+		"paramObj.paramInt":   42.0,
+		"paramObj.paramFloat": 4.2,
+	}
+	c.Set("paramString", "bar")
+	c.Set("paramInt", 42)
+	c.Set("paramArray", []string{"qux"})
+	c.Set("paramIntArray", []int{1, 2})
+	c.Set("paramObj", "scalar")
+	c.Set("paramMap", map[string]interface{}{"paramBool": true})
+
+	want := Config{
+		"paramString":        "bar",
+		"paramInt":           42.0,
+		"paramArray":         []string{"qux"},
+		"paramArray.0":       "qux",
+		"paramIntArray":      []float64{1, 2},
+		"paramIntArray.0":    1.0,
+		"paramIntArray.1":    2.0,
+		"paramObj":           "scalar",
+		"paramMap.paramBool": true,
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Config.Set() = %v, want %v", c, want)
+	}
+	if got := c.GetInt("paramIntArray.1"); got != 2 {
+		t.Errorf("Config.GetInt() = %v, want 2", got)
+	}
+
+	c.Set("paramMap", nil)
+	if c.Has("paramMap.paramBool") {
+		t.Errorf("Config.Set() with nil kept paramMap.paramBool")
+	}
+
+	c.Set("paramDuration", 5*time.Second)
+	c.Set("paramDurationArray", []time.Duration{time.Minute, 90 * time.Second})
+	if got := c.GetDuration("paramDuration"); got != 5*time.Second {
+		t.Errorf("Config.GetDuration() = %v, want 5s", got)
+	}
+	if got, want := c.GetDurationArray("paramDurationArray"), []time.Duration{time.Minute, 90 * time.Second}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Config.GetDurationArray() = %v, want %v", got, want)
+	}
+
+	c.Set("paramBig", int64(9007199254740993))
+	c.Set("paramBigUint", uint64(math.MaxUint64))
+	c.Set("paramBigArray", []int64{9007199254740993, 1})
+	if got := c.GetInt64("paramBig"); got != 9007199254740993 {
+		t.Errorf("Config.GetInt64() = %v, want 9007199254740993", got)
+	}
+	if got := c.GetString("paramBigUint"); got != "18446744073709551615" {
+		t.Errorf("Config.GetString() = %v, want 18446744073709551615", got)
+	}
+	if got := c.GetInt64("paramBigArray.0"); got != 9007199254740993 {
+		t.Errorf("Config.GetInt64() = %v, want 9007199254740993", got)
+	}
+}
+
+func TestConfig_WriteFile(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	for _, source := range []string{"complex-conf.json", "complex-conf.yaml"} {
+		for _, target := range []string{"conf-written.json", "conf-written.yaml"} {
+			t.Run(source+" To "+target, func(t *testing.T) {
+				want, err := Load(source)
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				if err := want.WriteFile(target); err != nil {
+					t.Fatalf("Config.WriteFile() error = %v", err)
+				}
+				defer os.Remove(target)
+				got, err := Load(target)
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Load() = %v, want %v", got, want)
+				}
+			})
+		}
+	}
+
+	big, err := LoadFromBytes([]byte(`{"arr": [9007199254740993, 1], "id": 9007199254740993}`), ".json")
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+	for _, target := range []string{"conf-big.json", "conf-big.yaml"} {
+		t.Run("Large Integers To "+target, func(t *testing.T) {
+			if err := big.WriteFile(target); err != nil {
+				t.Fatalf("Config.WriteFile() error = %v", err)
+			}
+			defer os.Remove(target)
+			got, err := Load(target)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, big) {
+				t.Errorf("Load() = %v, want %v", got, big)
+			}
+			if i := got.GetInt64("arr.0"); i != 9007199254740993 {
+				t.Errorf("Config.GetInt64() = %v, want 9007199254740993", i)
+			}
+		})
 	}
+
+	c := Config{
+		"paramObj.paramInt":    42.0,
+		"paramArray":           []string{"foo", "bar"},
+		"paramArray.0":         "foo",
+		"paramArray.1":         "bar",
+		"paramObjArray.0.name": "foo",
+		"paramObjArray.1.name": "bar",
+		"paramBig":             json.Number("12345678901234567"),
+	}
+	got, err := c.Marshal(".json")
+	if err != nil {
+		t.Fatalf("Config.Marshal() error = %v", err)
+	}
+	want := `{
+    "paramArray": [
+        "foo",
+        "bar"
+    ],
+    "paramBig": 12345678901234567,
+    "paramObj": {
+        "paramInt": 42
+    },
+    "paramObjArray": [
+        {
+            "name": "foo"
+        },
+        {
+            "name": "bar"
+        }
+    ]
+}`
+	if string(got) != want {
+		t.Errorf("Config.Marshal() = %s, want %s", got, want)
+	}
+	if _, err := c.Marshal(".hcl"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Config.Marshal() error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestConfig_Hash(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+
+	fromJSON, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	fromYAML, err := Load("complex-conf.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if fromJSON.Hash() != fromYAML.Hash() {
+		t.Errorf("Config.Hash() differs for equal configurations loaded from JSON and YAML")
+	}
+	if h := fromJSON.Hash(); len(h) != 64 || h != fromJSON.Hash() {
+		t.Errorf("Config.Hash() = %v, want a stable SHA-256 hex digest", h)
+	}
+
+	ints := Config{"paramInt": 42, "paramArray": []float64{1, 2}, "paramArray.0": 1, "paramArray.1": 2, "paramInts": []int{3}}
+	floats := Config{"paramInt": 42.0, "paramArray": []float64{1, 2}, "paramArray.0": 1.0, "paramArray.1": 2.0, "paramInts": []float64{3}}
+	if ints.Hash() != floats.Hash() {
+		t.Errorf("Config.Hash() differs for int and float representations of the same numbers")
+	}
+
+	changed := Config{"paramInt": 43.0, "paramArray": []float64{1, 2}, "paramArray.0": 1.0, "paramArray.1": 2.0, "paramInts": []float64{3}}
+	if changed.Hash() == floats.Hash() {
+		t.Errorf("Config.Hash() unchanged after a value change")
+	}
+	str := Config{"paramInt": "42", "paramArray": []float64{1, 2}, "paramArray.0": 1.0, "paramArray.1": 2.0, "paramInts": []float64{3}}
+	if str.Hash() == floats.Hash() {
+		t.Errorf("Config.Hash() equal for a string and a number")
+	}
+}
+
+func TestConfig_Merge(t *testing.T) {
+	base := Config{
+		"server.host": "localhost", "server.port": 80.0,
+		"paramShrink": []string{"foo", "bar", "baz"}, "paramShrink.0": "foo", "paramShrink.1": "bar", "paramShrink.2": "baz",
+		"paramGrow": []float64{1}, "paramGrow.0": 1.0,
+	}
+	override := Config{
+		"server.port": 8080.0,
+		"paramShrink": []string{"qux"}, "paramShrink.0": "qux",
+		"paramGrow": []float64{2, 3}, "paramGrow.0": 2.0, "paramGrow.1": 3.0,
+		"paramNew": true,
+	}
+	want := Config{
+		"server.host": "localhost", "server.port": 8080.0,
+		"paramShrink": []string{"qux"}, "paramShrink.0": "qux",
+		"paramGrow": []float64{2, 3}, "paramGrow.0": 2.0, "paramGrow.1": 3.0,
+		"paramNew": true,
+	}
+	base.Merge(override)
+	if !reflect.DeepEqual(base, want) {
+		t.Errorf("Config.Merge() = %v, want %v", base, want)
+	}
+}
+
+func TestConfig_OrderedSub(t *testing.T) {
+	confOrderedYAML := []byte(`
+paramString: foo
+paramObj:
+  zeta: 1
+  alpha: true
+  middle: [foo, bar]
+  alpha: false
+paramLast: bar`)
+	err := ioutil.WriteFile("conf-ordered.yaml", confOrderedYAML, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-ordered.yaml")
+	}
+	defer os.Remove("conf-ordered.yaml")
+
+	confOrderedJSON := []byte(`{
+    "paramString": "foo",
+    "paramObj": {
+        "zeta": 1,
+        "alpha": false,
+        "middle": ["foo", "bar"]
+    },
+    "paramLast": "bar"
+}`)
+	err = ioutil.WriteFile("conf-ordered.json", confOrderedJSON, 0644)
+	if err != nil {
+		t.Fatal("Could not generate test file conf-ordered.json")
+	}
+	defer os.Remove("conf-ordered.json")
+
 	tests := []struct {
-		name string
-		c    *Config
-		args args
-		want []time.Duration
+		name     string
+		filename string
+		prefix   string
+		want     []KeyValue
 	}{
 		{
-			name: "Get Duration Array",
-			args: args{p: "paramDurationArray"},
-			c:    &Config{"paramDurationArray": []string{"42ns", "5m", "10h10m"}},
-			want: []time.Duration{42 * time.Nanosecond, 5 * time.Minute, 10*time.Hour + 10*time.Minute},
+			name:     "YAML Declaration Order Under Prefix",
+			filename: "conf-ordered.yaml",
+			prefix:   "paramObj",
+			want: []KeyValue{
+				{Key: "zeta", Value: 1.0},
+				{Key: "middle", Value: []string{"foo", "bar"}},
+				{Key: "middle.0", Value: "foo"},
+				{Key: "middle.1", Value: "bar"},
+				{Key: "alpha", Value: false},
+			},
 		}, {
-			name: "Get Duration Array From Duration",
-			args: args{p: "paramDuration"},
-			c:    &Config{"paramDuration": "42ns"},
-			want: []time.Duration{42 * time.Nanosecond},
+			name:     "YAML Declaration Order Without Prefix",
+			filename: "conf-ordered.yaml",
+			prefix:   "",
+			want: []KeyValue{
+				{Key: "paramString", Value: "foo"},
+				{Key: "paramObj.zeta", Value: 1.0},
+				{Key: "paramObj.middle", Value: []string{"foo", "bar"}},
+				{Key: "paramObj.middle.0", Value: "foo"},
+				{Key: "paramObj.middle.1", Value: "bar"},
+				{Key: "paramObj.alpha", Value: false},
+				{Key: "paramLast", Value: "bar"},
+			},
+		}, {
+			name:     "JSON Sorted Order Under Prefix",
+			filename: "conf-ordered.json",
+			prefix:   "paramObj",
+			want: []KeyValue{
+				{Key: "alpha", Value: false},
+				{Key: "middle", Value: []string{"foo", "bar"}},
+				{Key: "middle.0", Value: "foo"},
+				{Key: "middle.1", Value: "bar"},
+				{Key: "zeta", Value: 1.0},
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.c.GetDurationArray(tt.args.p); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Config.GetDurationArray() = %v, want %v", got, tt.want)
+			c, order, err := LoadOrdered(tt.filename)
+			if err != nil {
+				t.Fatalf("LoadOrdered() error = %v", err)
+			}
+			if got := c.OrderedSub(tt.prefix, order); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.OrderedSub() = %v, want %v", got, tt.want)
 			}
 		})
 	}
+
+	t.Run("Order Is Not A Parameter", func(t *testing.T) {
+		c, order, err := LoadOrdered("conf-ordered.yaml")
+		if err != nil {
+			t.Fatalf("LoadOrdered() error = %v", err)
+		}
+		if len(c) != 7 {
+			t.Errorf("len(LoadOrdered()) = %v, want 7", len(c))
+		}
+		sub := c.Filter(func(k string, v interface{}) bool { return k != "paramString" })
+		if got := sub.OrderedSub("", order); len(got) != 6 || got[0].Key != "paramObj.zeta" {
+			t.Errorf("Config.Filter().OrderedSub() = %v, want declaration order", got)
+		}
+	})
+}
+
+func TestConfig_SaveCache(t *testing.T) {
+	generateTestFiles(t)
+	defer deleteTestFiles(t)
+	defer os.Remove("complex-conf.cache")
+
+	c, err := Load("complex-conf.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := c.SaveCache("complex-conf.cache"); err != nil {
+		t.Fatalf("Config.SaveCache() error = %v", err)
+	}
+
+	got, err := LoadCache("complex-conf.cache", "complex-conf.json")
+	if err != nil {
+		t.Fatalf("LoadCache() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("LoadCache() = %v, want %v", got, c)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes("complex-conf.json", future, future); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCache("complex-conf.cache", "complex-conf.json"); err == nil {
+		t.Errorf("LoadCache() error = %v with newer source, wantErr true", err)
+	}
 }
 
-func TestConfig_GetBoolArray(t *testing.T) {
-	type args struct {
-		p string
+func TestConfig_ToEnv(t *testing.T) {
+	c := &Config{
+		"paramString": "foo", "paramInt": 42.0, "paramBool": true,
+		"paramObj.paramFloat": 42.1, "param-dash": "bar",
+		"paramArray": []string{"foo", "bar"}, "paramArray.0": "foo", "paramArray.1": "bar",
 	}
 	tests := []struct {
-		name  string
-		c     *Config
-		args  args
-		wantA []bool
+		name   string
+		prefix string
+		want   []string
 	}{
 		{
-			name:  "Get Bool Array",
-			args:  args{p: "paramBoolArray"},
-			c:     &Config{"paramBoolArray": []bool{true, false, true}},
-			wantA: []bool{true, false, true},
-		}, {
-			name:  "Get Bool Array From Float Array",
-			args:  args{p: "paramFloatArray"},
-			c:     &Config{"paramFloatArray": []float64{0.0, 1.1, 2.1}},
-			wantA: []bool{false, true, true},
-		}, {
-			name:  "Get Bool Array From Bool",
-			args:  args{p: "paramBool"},
-			c:     &Config{"paramBool": true},
-			wantA: []bool{true},
-		}, {
-			name:  "Get Bool Array From Float (Non Zero)",
-			args:  args{p: "paramFloat"},
-			c:     &Config{"paramFloat": 42.1},
-			wantA: []bool{true},
+			name:   "Export Without Prefix",
+			prefix: "",
+			want: []string{
+				"PARAMARRAY=foo,bar", "PARAMBOOL=true", "PARAMINT=42",
+				"PARAMOBJ_PARAMFLOAT=42.1", "PARAMSTRING=foo", "PARAM_DASH=bar",
+			},
 		}, {
-			name:  "Get Bool Array From Float (Zero)",
-			args:  args{p: "paramFloat"},
-			c:     &Config{"paramFloat": 0.0},
-			wantA: []bool{false},
+			name:   "Export With Prefix",
+			prefix: "APP_",
+			want: []string{
+				"APP_PARAMARRAY=foo,bar", "APP_PARAMBOOL=true", "APP_PARAMINT=42",
+				"APP_PARAMOBJ_PARAMFLOAT=42.1", "APP_PARAMSTRING=foo", "APP_PARAM_DASH=bar",
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if gotA := tt.c.GetBoolArray(tt.args.p); !reflect.DeepEqual(gotA, tt.wantA) {
-				t.Errorf("Config.GetBoolArray() = %v, want %v", gotA, tt.wantA)
+			if got := c.ToEnv(tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Config.ToEnv() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -666,6 +5535,32 @@ paramObj:
 		t.Error("Could not generate test file complex-conf.yaml")
 	}
 
+	// complex-conf.hcl
+	complexConfHCL := []byte(`
+paramString = "foo"
+paramInt = 42
+paramFloat = 42.1
+paramBool = true
+paramDuration = "10h10m"
+paramObj {
+  paramIntArray = [0, 1, 2]
+  paramStringArray = ["foo", "bar", "baz"]
+  paramNested {
+    paramBool = false
+  }
+}
+resource "aws_s3" "x" {
+  bucket = "foo"
+}
+resource "aws_s3" "y" {
+  bucket = "bar"
+  versioning = true
+}`)
+	err = ioutil.WriteFile("complex-conf.hcl", complexConfHCL, 0644)
+	if err != nil {
+		t.Error("Could not generate test file complex-conf.hcl")
+	}
+
 	// invalid-conf.json
 	invalidConfJSON := []byte(`{{
     "paramString": "foo",
@@ -800,12 +5695,29 @@ paramObject: {}
 	}
 }
 
+// writeTestFiles writes the test files of files, mapping their name to
+// their content, and removes them when the test ends.
+func writeTestFiles(t *testing.T, files map[string]string) {
+	t.Cleanup(func() {
+		for file := range files {
+			os.Remove(file)
+		}
+	})
+	for file, content := range files {
+		err := ioutil.WriteFile(file, []byte(content), 0644)
+		if err != nil {
+			t.Fatal("Could not generate test file " + file)
+		}
+	}
+}
+
 func deleteTestFiles(t *testing.T) {
 	files := []string{
 		"simple-conf.json",
 		"simple-conf.yaml",
 		"complex-conf.json",
 		"complex-conf.yaml",
+		"complex-conf.hcl",
 		"invalid-conf.json",
 		"invalid-conf.yaml",
 		"conf-withenv.json",